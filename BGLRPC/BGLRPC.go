@@ -1,10 +1,12 @@
 package BGLRPC
 
 import (
+	"encoding/json"
 	"errors"
 	"log"
 
 	"gobglbridge/config"
+	"gobglbridge/types"
 
 	"github.com/bitgesellofficial/go-bgld"
 )
@@ -77,6 +79,9 @@ func (c *RPCClient) GetFromAddressForTransaction(txId string) (string, error) {
 	return rawTxObj.Vout[vin.Vout].ScriptPubKey.Address, nil
 }
 
-func (c *RPCClient) SendToAddress(address string, amount float64) (string, error) {
-	return c.Client.SendToAddress(address, amount, "", "")
+// amount is passed as exact 8 decimals number, never as float
+func (c *RPCClient) SendToAddress(address string, amount types.Amount) (string, error) {
+	var txId string
+	err := call("sendtoaddress", []interface{}{address, json.Number(amount.BGLString()), "", ""}, &txId)
+	return txId, err
 }
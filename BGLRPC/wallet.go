@@ -0,0 +1,91 @@
+package BGLRPC
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gobglbridge/config"
+)
+
+// wallet view of own transaction, bgld.Transaction lacks abandoned flag
+type WalletTransaction struct {
+	TxID            string   `json:"txid"`
+	Confirmations   int64    `json:"confirmations"` // negative if conflicted with a mined tx
+	WalletConflicts []string `json:"walletconflicts"`
+	Details         []struct {
+		Category  string `json:"category"`
+		Abandoned bool   `json:"abandoned"`
+	} `json:"details"`
+}
+
+func (t *WalletTransaction) Abandoned() bool {
+	for _, d := range t.Details {
+		if d.Abandoned {
+			return true
+		}
+	}
+	return false
+}
+
+// returned for txid unknown to the wallet
+var ErrUnknownTransaction = errors.New("invalid or non-wallet transaction id")
+
+func (c *RPCClient) GetWalletTransaction(txId string) (*WalletTransaction, error) {
+	var tx WalletTransaction
+	err := call("gettransaction", []interface{}{txId}, &tx)
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// plain JSON-RPC call to configured node, for methods go-bgld does not cover
+func call(method string, params []interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "1.0",
+		"id":      time.Now().UnixNano(),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", fmt.Sprintf("http://%s:%d", config.Config.BGL.Host, config.Config.BGL.Port), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(config.Config.BGL.RPCUser, config.Config.BGL.RPCPassword)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	if response.Error != nil {
+		// RPC_INVALID_ADDRESS_OR_KEY
+		if response.Error.Code == -5 {
+			return ErrUnknownTransaction
+		}
+		return fmt.Errorf("%s: %d: %s", method, response.Error.Code, response.Error.Message)
+	}
+	return json.Unmarshal(response.Result, result)
+}
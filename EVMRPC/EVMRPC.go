@@ -3,18 +3,21 @@ package EVMRPC
 import (
 	"fmt"
 	"log"
+	"strconv"
 
 	"gobglbridge/config"
+	"gobglbridge/metrics"
 
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 func WithClient[T any](chainId int, f func(client *ethclient.Client) (T, error)) (res T, err error) {
 	var client *ethclient.Client
-	for _, url := range config.EVMChains[chainId].RPCList {
+	for i, url := range config.EVMChains[chainId].RPCList {
 		client, err = ethclient.Dial(url)
 		if err != nil {
 			log.Println(fmt.Sprintf("Error connecting to %s: %s", url, err.Error()))
+			metrics.EVMRPCErrors.WithLabelValues(metrics.ChainName(chainId), strconv.Itoa(i)).Inc()
 			continue
 		}
 
@@ -23,6 +26,7 @@ func WithClient[T any](chainId int, f func(client *ethclient.Client) (T, error))
 		if err == nil {
 			return
 		}
+		metrics.EVMRPCErrors.WithLabelValues(metrics.ChainName(chainId), strconv.Itoa(i)).Inc()
 	}
 	return
 }
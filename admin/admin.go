@@ -0,0 +1,275 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gobglbridge/config"
+	"gobglbridge/store"
+	"gobglbridge/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// operator remediation shared by admin API and bridgectl: every action is an atomic
+// status change (types.ErrStatusConflict if a worker got there first) and is audited
+
+var (
+	ErrNotFound   = errors.New("bridge operation not found")
+	ErrNotAllowed = errors.New("action not allowed")
+)
+
+// statuses nothing is in flight for, so operator may decide what happens next
+var settledStatuses = []string{"failed", "returnfail", "held", "paused", "awaiting_approval"}
+
+// executor stops right after claiming when a signed tx could not be recorded, leaving operation
+// "executing" with no tx; operator checks custodian wallets before requeueing or resolving it
+func checkUnsent(op *types.BridgeOperation) error {
+	if op.Status == "executing" && (op.DestTxHash != "" || op.DestRawTx != "") {
+		return fmt.Errorf("%w: tx %s of executing operation may still be mined", ErrNotAllowed, op.DestTxHash)
+	}
+	return nil
+}
+
+// approved operation is bridged regardless of maximum amount
+func Approve(opID string, actor string, reason string) (*types.BridgeOperation, error) {
+	return transition(opID, actor, "approve", reason, []string{"awaiting_approval"}, func(op *types.BridgeOperation) error {
+		op.Approved = true
+		op.Status = "pending"
+		return nil
+	})
+}
+
+// rejected operation is returned to the sender by executor
+func Reject(opID string, actor string, reason string) (*types.BridgeOperation, error) {
+	return transition(opID, actor, "reject", reason, []string{"awaiting_approval"}, func(op *types.BridgeOperation) error {
+		if op.SourceChain == 0 && op.DestTxNonce != nil {
+			// pinned WBGL tx, returning BGL as well could pay twice
+			return fmt.Errorf("%w: WBGL tx %s may still be mined, approve instead", ErrNotAllowed, op.DestTxHash)
+		}
+		op.ForceReturn = true
+		op.Status = "pending"
+		return nil
+	})
+}
+
+// operation is bridged again by executor
+func Requeue(opID string, actor string, reason string) (*types.BridgeOperation, error) {
+	return transition(opID, actor, "requeue", reason, []string{"failed", "returnfail", "held", "paused", "executing"}, func(op *types.BridgeOperation) error {
+		if err := checkUnsent(op); err != nil {
+			return err
+		}
+		if op.SourceChain > 0 {
+			if op.DestTxNonce != nil {
+				// pinned WBGL return tx, sending BGL as well could pay twice
+				return fmt.Errorf("%w: WBGL return tx %s may still be mined, force return instead", ErrNotAllowed, op.DestTxHash)
+			}
+			op.DestChain = 0
+		} else if op.DestChain == 0 {
+			// BGL return was attempted, destination chain comes from the binding again
+			rec, err := store.DB.GetAddressBookBySourceAddress(types.CHAINKEY_BGL, op.SourceAddress)
+			if err != nil {
+				return err
+			}
+			if rec == nil {
+				return fmt.Errorf("%w: no binding for %s", ErrNotAllowed, op.SourceAddress)
+			}
+			op.DestChain = rec.DestChain
+		}
+		op.ForceReturn = false
+		op.Status = "pending"
+		return nil
+	})
+}
+
+// funds are returned to the sender by executor
+func ForceReturn(opID string, actor string, reason string) (*types.BridgeOperation, error) {
+	return transition(opID, actor, "return", reason, settledStatuses, func(op *types.BridgeOperation) error {
+		if op.SourceChain == 0 && op.DestTxNonce != nil {
+			// pinned WBGL tx, returning BGL as well could pay twice
+			return fmt.Errorf("%w: WBGL tx %s may still be mined, requeue instead", ErrNotAllowed, op.DestTxHash)
+		}
+		op.ForceReturn = true
+		op.Status = "pending"
+		return nil
+	})
+}
+
+// operation was settled outside of the bridge, reason tells how
+func Resolve(opID string, actor string, reason string) (*types.BridgeOperation, error) {
+	return transition(opID, actor, "resolve", reason, append([]string{"executing"}, settledStatuses...), func(op *types.BridgeOperation) error {
+		if err := checkUnsent(op); err != nil {
+			return err
+		}
+		op.Status = "resolved"
+		return nil
+	})
+}
+
+func transition(opID string, actor string, action string, reason string, from []string, apply func(op *types.BridgeOperation) error) (*types.BridgeOperation, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrNotAllowed)
+	}
+
+	op, err := store.DB.FindBridgeOperationByID(opID)
+	if err != nil {
+		return nil, err
+	}
+	if op == nil {
+		return nil, ErrNotFound
+	}
+
+	prevStatus := op.Status
+	allowed := false
+	for _, status := range from {
+		allowed = allowed || status == prevStatus
+	}
+	if !allowed {
+		return op, fmt.Errorf("%w: %s of %s operation", ErrNotAllowed, action, prevStatus)
+	}
+
+	if err := apply(op); err != nil {
+		return op, err
+	}
+	msg := fmt.Sprintf("%s by %s: %s", action, actor, reason)
+	op.AppendMessage(msg)
+
+	err = store.DB.ChangeBridgeOperationStatus(op, prevStatus)
+	if err != nil {
+		return op, err
+	}
+
+	audit(&types.AuditEntry{
+		Actor:  actor,
+		Action: action,
+		Target: op.ID,
+		Reason: reason,
+		Before: prevStatus,
+		After:  op.Status,
+	})
+	return op, nil
+}
+
+// creates binding or repairs existing one with the same source address, rec.ID is set
+func UpsertBinding(rec *types.AddressBookRecord, actor string, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("%w: reason is required", ErrNotAllowed)
+	}
+
+	// exactly one side is BGL, EVM side is a configured chain
+	evmChain, evmAddress, bglAddress := rec.DestChain, rec.DestAddress, rec.SourceAddress
+	chainType := types.CHAINKEY_BGL
+	if rec.SourceChain > 0 {
+		evmChain, evmAddress, bglAddress = rec.SourceChain, rec.SourceAddress, rec.DestAddress
+		chainType = types.CHAINKEY_EVM
+	}
+	if rec.SourceChain > 0 && rec.DestChain > 0 {
+		return fmt.Errorf("%w: binding must have BGL on one side", ErrNotAllowed)
+	}
+	if _, ok := config.EVMChains[evmChain]; !ok {
+		return fmt.Errorf("%w: unknown chain %d", ErrNotAllowed, evmChain)
+	}
+	if !common.IsHexAddress(evmAddress) {
+		return fmt.Errorf("%w: invalid EVM address %q", ErrNotAllowed, evmAddress)
+	}
+	if bglAddress == "" {
+		return fmt.Errorf("%w: BGL address is required", ErrNotAllowed)
+	}
+
+	before := ""
+	existing, err := store.DB.GetAddressBookBySourceAddress(chainType, rec.SourceAddress)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		rec.ID = existing.ID
+		rec.TsCreated = existing.TsCreated
+		before = fmt.Sprintf("%d:%s", existing.DestChain, existing.DestAddress)
+	}
+	if rec.TsCreated == 0 {
+		rec.TsCreated = time.Now().Unix()
+	}
+
+	err = store.DB.UpsertAddressBookRecord(rec)
+	if err != nil {
+		return err
+	}
+
+	audit(&types.AuditEntry{
+		Actor:  actor,
+		Action: "binding",
+		Target: rec.ID,
+		Reason: reason,
+		Before: before,
+		After:  fmt.Sprintf("%d:%s", rec.DestChain, rec.DestAddress),
+	})
+	return nil
+}
+
+// scanner picks BGL checkpoint up on its next round, deposits are never recorded twice
+func SetBGLCheckpoint(blockHash string, actor string, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("%w: reason is required", ErrNotAllowed)
+	}
+
+	before, err := store.DB.GetBGLScannedBlock()
+	if err != nil {
+		return err
+	}
+	err = store.DB.SetBGLScannedBlock(blockHash)
+	if err != nil {
+		return err
+	}
+
+	audit(&types.AuditEntry{
+		Actor:  actor,
+		Action: "checkpoint",
+		Target: "bgl",
+		Reason: reason,
+		Before: before,
+		After:  blockHash,
+	})
+	return nil
+}
+
+// scanner picks EVM checkpoint up on its next round, deposits are never recorded twice
+func SetEVMCheckpoint(chainId int, blockHeight int, actor string, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("%w: reason is required", ErrNotAllowed)
+	}
+	if _, ok := config.EVMChains[chainId]; !ok {
+		return fmt.Errorf("%w: unknown chain %d", ErrNotAllowed, chainId)
+	}
+
+	before, err := store.DB.GetEVMScannedBlock(chainId)
+	if err != nil {
+		return err
+	}
+	err = store.DB.SetEVMScannedBlock(chainId, blockHeight)
+	if err != nil {
+		return err
+	}
+
+	audit(&types.AuditEntry{
+		Actor:  actor,
+		Action: "checkpoint",
+		Target: config.EVMChains[chainId].ShortCode,
+		Reason: reason,
+		Before: strconv.Itoa(before),
+		After:  strconv.Itoa(blockHeight),
+	})
+	return nil
+}
+
+// action is already applied, failing to store the entry is only logged
+func audit(entry *types.AuditEntry) {
+	entry.Ts = time.Now().Unix()
+	log.Printf("AUDIT: %s %s of %s (%s -> %s): %s", entry.Actor, entry.Action, entry.Target, entry.Before, entry.After, entry.Reason)
+	if err := store.DB.AppendAudit(entry); err != nil {
+		log.Printf("Error writing audit log entry: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"errors"
+	"testing"
+
+	"gobglbridge/store"
+	"gobglbridge/types"
+)
+
+// memory store restored after test
+func setStore(t *testing.T) {
+	t.Helper()
+	db := store.DB
+	t.Cleanup(func() { store.DB = db })
+	store.DB = store.NewMemory()
+}
+
+func storeOp(t *testing.T, op *types.BridgeOperation) string {
+	t.Helper()
+	if err := store.DB.UpsertBridgeOperation(op); err != nil {
+		t.Fatal(err)
+	}
+	return op.ID
+}
+
+func TestExecutingWithoutTx(t *testing.T) {
+	setStore(t)
+
+	// left by executor emergency exit, nothing was signed
+	unsent := storeOp(t, &types.BridgeOperation{Status: "executing", SourceTxHash: "0xa", DestChain: 1})
+	op, err := Requeue(unsent, "test", "executor stopped before signing")
+	if err != nil {
+		t.Fatalf("Requeue() of unsent executing op: %v", err)
+	}
+	if op.Status != "pending" {
+		t.Errorf("requeued op status = %s", op.Status)
+	}
+
+	unsent = storeOp(t, &types.BridgeOperation{Status: "executing", SourceTxHash: "0xb", SourceChain: 1})
+	if op, err := Resolve(unsent, "test", "paid manually"); err != nil || op.Status != "resolved" {
+		t.Fatalf("Resolve() of unsent executing op = %v, %v", op, err)
+	}
+
+	// recorded tx is tracked by workers
+	sent := storeOp(t, &types.BridgeOperation{Status: "executing", SourceTxHash: "0xc", DestChain: 1, DestTxHash: "0xdest"})
+	if _, err := Requeue(sent, "test", "retry"); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Requeue() of sent executing op error = %v", err)
+	}
+	if _, err := Resolve(sent, "test", "done"); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Resolve() of sent executing op error = %v", err)
+	}
+	signed := storeOp(t, &types.BridgeOperation{Status: "executing", SourceTxHash: "0xd", DestChain: 1, DestRawTx: "0x02"})
+	if _, err := Resolve(signed, "test", "done"); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Resolve() of signed executing op error = %v", err)
+	}
+	if found, _ := store.DB.FindBridgeOperationByID(sent); found.Status != "executing" {
+		t.Errorf("refused op status = %s", found.Status)
+	}
+
+	// force return is still only for settled operations
+	if _, err := ForceReturn(sent, "test", "return"); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("ForceReturn() of executing op error = %v", err)
+	}
+}
+
+func TestRejectPinnedTx(t *testing.T) {
+	setStore(t)
+
+	nonce := uint64(7)
+	pinned := storeOp(t, &types.BridgeOperation{Status: "awaiting_approval", SourceTxHash: "0xa", DestChain: 1, DestTxHash: "0xold", DestTxNonce: &nonce})
+	if _, err := Reject(pinned, "test", "too large"); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Reject() of op with pinned WBGL tx error = %v", err)
+	}
+
+	op, err := Reject(storeOp(t, &types.BridgeOperation{Status: "awaiting_approval", SourceTxHash: "0xb", DestChain: 1}), "test", "too large")
+	if err != nil || !op.ForceReturn || op.Status != "pending" {
+		t.Errorf("Reject() = %v, %v", op, err)
+	}
+}
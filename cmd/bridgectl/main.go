@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gobglbridge/BGLRPC"
+	"gobglbridge/EVMRPC"
+	"gobglbridge/config"
+	"gobglbridge/types"
+	"gobglbridge/workers/handlers"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// operator CLI, run from the server directory (reads the same config.yml);
+// operations, bindings and checkpoints go through the admin API of the running server
+// (admin_url, admin_token), so storage is never opened twice and every change is audited
+
+const usage = `usage: bridgectl <command> [args]
+
+  op <id|tx hash>                         show operation
+  list <status>                           list operations in status
+  requeue <id> <reason>                   send operation to executor again
+  return <id> <reason>                    return funds to sender
+  resolve <id> <reason>                   mark operation settled outside of bridge
+  checkpoints                             show scan checkpoints
+  set-checkpoint <bgl|code> <block> <reason>
+                                          reset scan checkpoint (BGL block hash, EVM block height)
+  bindings [address]                      dump address book records
+  balances                                print custodian balances
+  audit [limit]                           show operator actions, newest first
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	config.Init()
+
+	ok, err := run(os.Args[1], os.Args[2:])
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// false if command or its arguments are unknown
+func run(cmd string, args []string) (bool, error) {
+	switch {
+	case cmd == "op" && len(args) == 1:
+		return true, showOperation(args[0])
+	case cmd == "list" && len(args) == 1:
+		return true, listOperations(args[0])
+	case (cmd == "requeue" || cmd == "return" || cmd == "resolve") && len(args) >= 2:
+		return true, changeOperation(cmd, args[0], strings.Join(args[1:], " "))
+	case cmd == "checkpoints" && len(args) == 0:
+		return true, showCheckpoints()
+	case cmd == "set-checkpoint" && len(args) >= 3:
+		return true, setCheckpoint(args[0], args[1], strings.Join(args[2:], " "))
+	case cmd == "bindings" && len(args) <= 1:
+		return true, listBindings(args)
+	case cmd == "balances" && len(args) == 0:
+		return true, showBalances()
+	case cmd == "audit" && len(args) <= 1:
+		return true, showAudit(args)
+	}
+	return false, nil
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "bridgectl: %v\n", err)
+	os.Exit(1)
+}
+
+// operator name for audit log
+func actor() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("bridgectl:%s@%s", name, host)
+}
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// calls admin API, response is decoded into out
+func call(method string, path string, body interface{}, out interface{}) error {
+	if config.Config.Server.AdminToken == "" {
+		return fmt.Errorf("admin_token is not configured, admin API is disabled")
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, config.Config.Server.AdminURL+"/admin"+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+config.Config.Server.AdminToken)
+	req.Header.Set("X-Operator", actor())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("admin API: %w (is the server running?)", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr handlers.APIResponse
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("%s (HTTP %d)", apiErr.Message, resp.StatusCode)
+		}
+		return fmt.Errorf("admin API: HTTP %d", resp.StatusCode)
+	}
+	return json.Unmarshal(data, out)
+}
+
+func chainCode(chainId int) string {
+	if chainId == 0 {
+		return "bgl"
+	}
+	if chain, ok := config.EVMChains[chainId]; ok {
+		return chain.ShortCode
+	}
+	return strconv.Itoa(chainId)
+}
+
+func printJSON(v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// by ID, deposit tx hash or payout tx hash
+func showOperation(key string) error {
+	var op types.BridgeOperation
+	if err := call(http.MethodGet, "/ops/"+url.PathEscape(key), nil, &op); err != nil {
+		return err
+	}
+	return printJSON(&op)
+}
+
+// requeue, return or resolve
+func changeOperation(action string, opID string, reason string) error {
+	var op types.BridgeOperation
+	err := call(http.MethodPost, "/ops/"+url.PathEscape(opID)+"/"+action, &handlers.AdminReasonRequest{Reason: reason}, &op)
+	if err != nil {
+		return err
+	}
+	return printJSON(&op)
+}
+
+func listOperations(status string) error {
+	var ops []*types.BridgeOperation
+	if err := call(http.MethodGet, "/ops?status="+url.QueryEscape(status), nil, &ops); err != nil {
+		return err
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].TsFound < ops[j].TsFound })
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tROUTE\tAMOUNT\tFOUND\tUPDATED\tMESSAGE")
+	for _, op := range ops {
+		amount := op.Amount
+		if a, err := op.SourceAmount(); err == nil {
+			amount = a.String()
+		}
+		fmt.Fprintf(w, "%s\t%s->%s\t%s\t%s\t%s\t%s\n",
+			op.ID,
+			chainCode(op.SourceChain),
+			chainCode(op.DestChain),
+			amount,
+			formatTs(op.TsFound),
+			formatTs(op.TsUpdated),
+			op.Message,
+		)
+	}
+	w.Flush()
+	fmt.Printf("%d %s operations\n", len(ops), status)
+	return nil
+}
+
+func formatTs(ts int64) string {
+	if ts == 0 {
+		return "-"
+	}
+	return time.Unix(ts, 0).UTC().Format("2006-01-02 15:04:05")
+}
+
+func showCheckpoints() error {
+	var checkpoints []handlers.AdminCheckpoint
+	if err := call(http.MethodGet, "/checkpoints", nil, &checkpoints); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHAIN\tCHECKPOINT")
+	for _, checkpoint := range checkpoints {
+		fmt.Fprintf(w, "%s\t%s\n", checkpoint.Chain, checkpoint.Block)
+	}
+	return w.Flush()
+}
+
+// scanners pick it up on their next round
+func setCheckpoint(code string, block string, reason string) error {
+	var resp handlers.APIResponse
+	return call(http.MethodPost, "/checkpoints/"+url.PathEscape(code), &handlers.AdminCheckpointRequest{Block: block, Reason: reason}, &resp)
+}
+
+// all records, or the ones with address on either side
+func listBindings(args []string) error {
+	path := "/bindings"
+	if len(args) == 1 {
+		path += "?address=" + url.QueryEscape(args[0])
+	}
+	var recs []*types.AddressBookRecord
+	if err := call(http.MethodGet, path, nil, &recs); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSOURCE\tDESTINATION\tCREATED")
+	for _, rec := range recs {
+		fmt.Fprintf(w, "%s\t%s:%s\t%s:%s\t%s\n",
+			rec.ID,
+			chainCode(rec.SourceChain),
+			rec.SourceAddress,
+			chainCode(rec.DestChain),
+			rec.DestAddress,
+			formatTs(rec.TsCreated),
+		)
+	}
+	w.Flush()
+	fmt.Printf("%d bindings\n", len(recs))
+	return nil
+}
+
+// BGL wallet, WBGL and gas coin of custodian on every chain
+func showBalances() error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHAIN\tASSET\tBALANCE")
+
+	balanceBGL, err := BGLRPC.GetClient().GetBalance()
+	if err != nil {
+		fmt.Fprintf(w, "bgl\tBGL\terror: %v\n", err)
+	} else {
+		fmt.Fprintf(w, "bgl\tBGL\t%s\n", types.AmountFromBGLFloat(balanceBGL).String())
+	}
+
+	custodian := common.HexToAddress(config.Config.EVM.PublicAddress)
+	for _, chainId := range config.ChainIDs() {
+		balanceWBGL, err := handlers.WBGLBalanceInt(chainId)
+		if err != nil {
+			fmt.Fprintf(w, "%s\tWBGL\terror: %v\n", chainCode(chainId), err)
+		} else {
+			fmt.Fprintf(w, "%s\tWBGL\t%s\n", chainCode(chainId), types.AmountFromWei(balanceWBGL).String())
+		}
+
+		balanceGas, err := EVMRPC.WithClient(
+			chainId, func(client *ethclient.Client) (*big.Int, error) {
+				return client.BalanceAt(context.Background(), custodian, nil)
+			},
+		)
+		if err != nil {
+			fmt.Fprintf(w, "%s\tgas\terror: %v\n", chainCode(chainId), err)
+		} else {
+			// native coins have 18 decimals like WBGL
+			fmt.Fprintf(w, "%s\tgas\t%s\n", chainCode(chainId), types.AmountFromWei(balanceGas).String())
+		}
+	}
+	return w.Flush()
+}
+
+func showAudit(args []string) error {
+	limit := 50
+	if len(args) == 1 {
+		var err error
+		limit, err = strconv.Atoi(args[0])
+		if err != nil || limit <= 0 {
+			return fmt.Errorf("invalid limit %q", args[0])
+		}
+	}
+
+	var entries []*types.AuditEntry
+	if err := call(http.MethodGet, "/audit?limit="+strconv.Itoa(limit), nil, &entries); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tACTOR\tACTION\tTARGET\tCHANGE\tREASON")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s -> %s\t%s\n",
+			formatTs(entry.Ts),
+			entry.Actor,
+			entry.Action,
+			entry.Target,
+			entry.Before,
+			entry.After,
+			entry.Reason,
+		)
+	}
+	return w.Flush()
+}
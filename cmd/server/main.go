@@ -1,16 +1,24 @@
 package main
 
 import (
+	"context"
+	"embed"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"time"
 
 	"gobglbridge/config"
-	"gobglbridge/redis"
+	"gobglbridge/store"
 	"gobglbridge/workers"
 )
 
+// static web app, compiled into the binary
+//
+//go:embed app
+var app embed.FS
+
 func main() {
 	log.Print("Starting BGL/WBGL bridge")
 
@@ -26,20 +34,42 @@ func main() {
 	// this is for debug, makes output contain sensitive info
 	fmt.Printf("%+v", config.Config)
 
-	// connect to Redis, without persistence do not continue
-	redis.Init()
+	// open storage (Redis or embedded), without persistence do not continue
+	store.Init()
+	defer store.DB.Close()
 
-	// there are 7 worker threads:
+	// worker threads:
 	// * listen to BGL blocks
-	// * listen to Eth, BNB, Optimism, Arbitrum blocks
+	// * listen to every configured EVM chain blocks (Eth, BNB, Optimism, Arbitrum by default)
 	// * execute pending transactions
-	// * static app service and API serving HTTPS server (serves as main worker thread)
-	go workers.Worker_scanBGL()
-	go workers.Worker_scanEVM(1)
-	go workers.Worker_scanEVM(10)
-	go workers.Worker_scanEVM(56)
-	go workers.Worker_scanEVM(42161)
-	go workers.Worker_processExecution()
-
-	workers.Worker_HTTP()
+	// * check receipts of sent EVM transactions
+	// * follow sent BGL transactions to required confirmations
+	// * keep custodian nonces in sync with chains, fill nonce gaps
+	// * archive finished operations
+	// * refresh custodian balances for metrics
+	// * static app service and API serving HTTPS server
+	// all workers share root context, cancelled on SIGINT/SIGTERM,
+	// in-flight sends and status updates are finished before exit
+	ctx := workers.Context()
+
+	appFS, _ := fs.Sub(app, "app")
+
+	workers.ReconcileNonces(ctx)
+
+	workers.Run(ctx, workers.Worker_scanBGL)
+	for _, chainId := range config.ChainIDs() {
+		chainId := chainId
+		workers.Run(ctx, func(ctx context.Context) { workers.Worker_scanEVM(ctx, chainId) })
+	}
+	workers.Run(ctx, workers.Worker_processExecution)
+	workers.Run(ctx, workers.Worker_trackEVM)
+	workers.Run(ctx, workers.Worker_trackBGL)
+	workers.Run(ctx, workers.Worker_nonces)
+	workers.Run(ctx, workers.Worker_caps)
+	workers.Run(ctx, workers.Worker_archive)
+	workers.Run(ctx, workers.Worker_balances)
+	workers.Run(ctx, func(ctx context.Context) { workers.Worker_HTTP(ctx, appFS) })
+
+	workers.Wait()
+	log.Print("BGL/WBGL bridge stopped")
 }
@@ -1,11 +1,28 @@
 package config
 
+import (
+	"math/big"
+	"sort"
+	"strings"
+)
+
 type Configuration struct {
 	// Server config
 	Server struct {
 		UseSSL    bool   `yaml:"ssl"`
 		RedisPort int    `yaml:"redis_port"`
 		RedisHost string `yaml:"redis_host"`
+		// persistence backend: "redis" (default), "bolt" (single file) or "memory" (no persistence)
+		Storage     string `yaml:"storage"`
+		StoragePath string `yaml:"storage_path"` // database file for "bolt" storage
+		// finished operations older than this are moved to monthly archive, 0 disables
+		ArchiveAfterDays int `yaml:"archive_after_days"`
+		// serve web app from this directory instead of the one embedded into binary
+		AppDir string `yaml:"app_dir"`
+		// bearer token of /admin endpoints, admin API is disabled if empty
+		AdminToken string `yaml:"admin_token"`
+		// admin API base URL used by bridgectl, this server on localhost by default
+		AdminURL string `yaml:"admin_url"`
 	} `yaml:"server"`
 	// BGL-related config
 	BGL struct {
@@ -21,8 +38,32 @@ type Configuration struct {
 	EVM struct {
 		PublicAddress string `yaml:"address"`
 		PrivateKey    string `yaml:"private_key"`
+		// failed (reverted, dropped) destination txs are re-driven at most this many times
+		MaxRetries int `yaml:"max_retries"`
+		// minutes after which a tx unknown to RPC nodes is considered never mined
+		DropTimeout int `yaml:"drop_timeout"`
 	} `yaml:"EVM"`
+	// default bridge fee when Fees has no rule matching the operation
 	FeePercentage int `yaml:"fee_percentage"`
+	// fee rules, the most specific rule matching direction and chain applies
+	Fees []FeeRule `yaml:"fees"`
+	// bridge amount limits, the most specific rule matching direction and chain applies;
+	// amounts above maximum wait for approval, below minimum (or not covering the fee) are held or returned by BelowMin
+	Limits struct {
+		BelowMin string      `yaml:"below_min"` // "hold" (default) or "return"
+		Rules    []LimitRule `yaml:"rules"`
+	} `yaml:"limits"`
+	// rolling payout caps, the most specific rule matching direction and chain applies;
+	// payouts that would exceed a cap are paused until the window has room again
+	Caps []CapRule `yaml:"caps"`
+	// sub-satoshi remainder of WBGL to BGL transfers: "revenue" (default) keeps it as fee,
+	// "refund" adds it to a later payout to the same sender once it reaches RefundThreshold wei
+	Dust struct {
+		Policy          string `yaml:"policy"`
+		RefundThreshold int64  `yaml:"refund_threshold"`
+	} `yaml:"dust"`
+	// EVM chains the bridge operates on, built-in defaults are used if empty
+	Chains []ChainConfig `yaml:"chains"`
 }
 
 var Config Configuration
@@ -35,61 +76,159 @@ const EVM_RETRIES = 3
 
 // EVM-chains configs
 type ChainConfig struct {
-	Name             string
-	ChainID          int
-	RPCList          []string
-	ContractAddress  string // WBGL token address
-	MinConfirmations int
-	BlockBatch       int
+	Name             string   `yaml:"name"`
+	ShortCode        string   `yaml:"code"` // used by API and webapp, e.g. "eth"
+	ChainID          int      `yaml:"chain_id"`
+	RPCList          []string `yaml:"rpc"`
+	ContractAddress  string   `yaml:"contract"` // WBGL token address
+	MinConfirmations int      `yaml:"confirmations"`
+	BlockBatch       int      `yaml:"batch_size"`
 	// StartingBlock    int // from when to start scan if no previous record
-	SafetyWindow int // as logs go in another thread, make some room, and also to pickup txs sent by bridge to finalize
+	SafetyWindow int       `yaml:"safety_window"` // as logs go in another thread, make some room, and also to pickup txs sent by bridge to finalize
+	Gas          GasPolicy `yaml:"gas"`
+}
+
+// how bridge transactions are priced on a chain
+type GasPolicy struct {
+	// EIP-1559 txs pay TipPercent of suggested priority fee, max fee per gas is
+	// BaseFeePercent of latest base fee plus the tip (100 and 200 if not set)
+	TipPercent     int  `yaml:"tip_percent"`
+	BaseFeePercent int  `yaml:"base_fee_percent"`
+	Legacy         bool `yaml:"legacy"` // legacy txs even if chain supports EIP-1559
+	// legacy txs pay percent of suggested gas price, 100 if not set
+	PricePercent int `yaml:"price_percent"`
+	// cap on gas price or max fee per gas, no replacement of stuck txs if not set
+	MaxPriceGwei float64 `yaml:"max_price_gwei"`
+	// stuck txs are replaced (same nonce) with fees raised by BumpPercent every BumpAfter minutes
+	BumpAfter   int `yaml:"bump_after"`
+	BumpPercent int `yaml:"bump_percent"`
+	// gas limit is estimated transfer gas plus LimitMarginPercent, txs needing more than MaxGasLimit are not sent
+	LimitMarginPercent int    `yaml:"limit_margin_percent"`
+	MaxGasLimit        uint64 `yaml:"max_gas_limit"`
+}
+
+// gas price ceiling in wei, nil if not set
+func (g GasPolicy) MaxPrice() *big.Int {
+	if g.MaxPriceGwei <= 0 {
+		return nil
+	}
+	wei, _ := new(big.Float).Mul(big.NewFloat(g.MaxPriceGwei), big.NewFloat(1e9)).Int(nil)
+	return wei
+}
+
+// bridge fee is Bps of amount limited to [Min, Max], plus Flat; amounts are BGL decimals
+type FeeRule struct {
+	Direction string `yaml:"direction"` // "bgl_to_wbgl", "wbgl_to_bgl" or empty for both
+	Chain     string `yaml:"chain"`     // chain code or empty for all
+	Bps       int64  `yaml:"bps"`       // basis points, 100 = 1%
+	Min       string `yaml:"min"`
+	Max       string `yaml:"max"`
+	Flat      string `yaml:"flat"` // e.g. to cover Ethereum mainnet gas
+}
+
+// deposit limits in BGL decimals, empty is no limit
+type LimitRule struct {
+	Direction string `yaml:"direction"` // "bgl_to_wbgl", "wbgl_to_bgl" or empty for both
+	Chain     string `yaml:"chain"`     // chain code or empty for all
+	Min       string `yaml:"min"`
+	Max       string `yaml:"max"`
 }
 
-var EVMChains = map[int]ChainConfig{
-	1: {
+// payout volume caps in BGL decimals over the last hour and day, empty is no cap
+type CapRule struct {
+	Direction string `yaml:"direction"` // "bgl_to_wbgl", "wbgl_to_bgl" or empty for both
+	Chain     string `yaml:"chain"`     // chain code or empty for all
+	Hourly    string `yaml:"hourly"`
+	Daily     string `yaml:"daily"`
+}
+
+// fee directions
+const (
+	DirectionBGLToWBGL = "bgl_to_wbgl"
+	DirectionWBGLToBGL = "wbgl_to_bgl"
+)
+
+// chain ID -> chain config, populated by Init from Config.Chains
+var EVMChains = map[int]ChainConfig{}
+
+// used when config.yml has no chains section
+var defaultEVMChains = []ChainConfig{
+	{
 		Name:             "Eth",
+		ShortCode:        "eth",
 		ChainID:          1,
 		RPCList:          []string{"https://eth.drpc.org", "https://eth.llamarpc.com"},
 		ContractAddress:  "0x2bA64EFB7A4Ec8983E22A49c81fa216AC33f383A",
 		MinConfirmations: 3,
 		BlockBatch:       512,
 		SafetyWindow:     10,
+		Gas:              GasPolicy{PricePercent: 100, MaxPriceGwei: 100},
 	}, // Ethereum
-	10: {
+	{
 		Name:             "Optimism",
+		ShortCode:        "op",
 		ChainID:          10,
 		RPCList:          []string{"https://rpc.ankr.com/optimism", "https://optimism.llamarpc.com", "https://optimism.drpc.org"},
 		ContractAddress:  "0x2bA64EFB7A4Ec8983E22A49c81fa216AC33f383A",
 		MinConfirmations: 3,
 		BlockBatch:       512,
 		SafetyWindow:     100,
+		Gas:              GasPolicy{PricePercent: 200, MaxPriceGwei: 1},
 	}, // Optimism
-	56: {
+	{
 		Name:             "BNB",
+		ShortCode:        "bnb",
 		ChainID:          56,
 		RPCList:          []string{"https://rpc.ankr.com/bsc", "https://bsc.drpc.org", "https://bsc.meowrpc.com"},
 		ContractAddress:  "0x2bA64EFB7A4Ec8983E22A49c81fa216AC33f383A",
 		MinConfirmations: 3,
 		BlockBatch:       512,
 		SafetyWindow:     25,
+		Gas:              GasPolicy{PricePercent: 200, MaxPriceGwei: 10},
 	}, // BNB
-	42161: {
+	{
 		Name:             "Arbitrum",
+		ShortCode:        "arb",
 		ChainID:          42161,
 		RPCList:          []string{"https://rpc.ankr.com/arbitrum", "https://arbitrum.llamarpc.com", "https://arbitrum.meowrpc.com"},
 		ContractAddress:  "0x2bA64EFB7A4Ec8983E22A49c81fa216AC33f383A",
 		MinConfirmations: 3,
 		BlockBatch:       512,
 		SafetyWindow:     100,
+		Gas:              GasPolicy{PricePercent: 200, MaxPriceGwei: 1},
 	}, // Arbitrum
 }
 
+// chain by API short code, e.g. "bnb"
+func ChainByCode(code string) (ChainConfig, bool) {
+	for _, chain := range EVMChains {
+		if strings.EqualFold(chain.ShortCode, code) {
+			return chain, true
+		}
+	}
+	return ChainConfig{}, false
+}
+
+// configured chain IDs in ascending order
+func ChainIDs() []int {
+	ids := make([]int, 0, len(EVMChains))
+	for id := range EVMChains {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
 var RedisStatusSets = map[string]string{
-	"pending":       "bridgeops:pending",       // souce transaction was scanned
-	"failed":        "bridgeops:failed",        // failed to process, error occured and cannot return funds
-	"executing":     "bridgeops:executing",     // desination transaction sent successfully
-	"success":       "bridgeops:success",       // destination transaction entered block and was scanned
-	"returning":     "bridgeops:returning",     // tried to return funds because destination has not enough BGL or gas
-	"returnfail":    "bridgeops:returnfail",    // tried to initiate return but encountered a fn error
-	"returnsuccess": "brdigeops:returnsuccess", // funds returned successfully
+	"pending":           "bridgeops:pending",           // souce transaction was scanned
+	"failed":            "bridgeops:failed",            // failed to process, error occured and cannot return funds
+	"executing":         "bridgeops:executing",         // desination transaction sent successfully
+	"success":           "bridgeops:success",           // destination transaction entered block and was scanned
+	"returning":         "bridgeops:returning",         // tried to return funds because destination has not enough BGL or gas
+	"returnfail":        "bridgeops:returnfail",        // tried to initiate return but encountered a fn error
+	"returnsuccess":     "brdigeops:returnsuccess",     // funds returned successfully
+	"held":              "bridgeops:held",              // amount below minimum, kept for operator
+	"awaiting_approval": "bridgeops:awaiting_approval", // amount above maximum, waits for operator approval
+	"paused":            "bridgeops:paused",            // payout would exceed route volume cap, resumed when window has room
+	"resolved":          "bridgeops:resolved",          // settled manually by operator, see message
 }
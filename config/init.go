@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+
+	"gobglbridge/types"
 
 	"github.com/kelseyhightower/envconfig"
 	yaml "gopkg.in/yaml.v2"
@@ -35,7 +38,155 @@ func readEnv(cfg *Configuration) {
 	}
 }
 
+// chain registry is checked once, so workers and handlers can rely on it
+func initChains(cfg *Configuration) {
+	if len(cfg.Chains) == 0 {
+		cfg.Chains = defaultEVMChains
+	}
+
+	EVMChains = map[int]ChainConfig{}
+	codes := map[string]bool{}
+	for _, chain := range cfg.Chains {
+		if chain.ChainID <= 0 {
+			processError(fmt.Errorf("chain %q: chain_id must be positive (0 is BGL)", chain.Name))
+		}
+		if _, ok := EVMChains[chain.ChainID]; ok {
+			processError(fmt.Errorf("chain %q: duplicate chain_id %d", chain.Name, chain.ChainID))
+		}
+		code := strings.ToLower(chain.ShortCode)
+		if code == "" || code == "bgl" || codes[code] {
+			processError(fmt.Errorf("chain %q: missing, reserved or duplicate code %q", chain.Name, chain.ShortCode))
+		}
+		if len(chain.RPCList) == 0 || chain.ContractAddress == "" {
+			processError(fmt.Errorf("chain %q: rpc and contract are required", chain.Name))
+		}
+		if chain.BlockBatch <= 0 {
+			processError(fmt.Errorf("chain %q: batch_size must be positive", chain.Name))
+		}
+		if chain.Gas.TipPercent == 0 {
+			chain.Gas.TipPercent = 100
+		}
+		if chain.Gas.BaseFeePercent == 0 {
+			chain.Gas.BaseFeePercent = 200
+		}
+		if chain.Gas.PricePercent == 0 {
+			chain.Gas.PricePercent = 100
+		}
+		if chain.Gas.LimitMarginPercent == 0 {
+			chain.Gas.LimitMarginPercent = 20
+		}
+		if chain.Gas.MaxGasLimit == 0 {
+			chain.Gas.MaxGasLimit = 200000
+		}
+		if chain.Gas.BumpAfter == 0 {
+			chain.Gas.BumpAfter = 10
+		}
+		// nodes reject replacements paying less than 10% more
+		if chain.Gas.BumpPercent < 10 {
+			chain.Gas.BumpPercent = 20
+		}
+		codes[code] = true
+		EVMChains[chain.ChainID] = chain
+	}
+}
+
+// fee rules are checked once, so fee engine can rely on them
+func initFees(cfg *Configuration) {
+	// catch-all rule from fee_percentage, applies when nothing more specific matches
+	cfg.Fees = append(cfg.Fees, FeeRule{Bps: int64(cfg.FeePercentage) * 100})
+
+	for _, rule := range cfg.Fees {
+		checkRoute("fee rule", rule.Direction, rule.Chain)
+		if rule.Bps < 0 || rule.Bps > 10000 {
+			processError(fmt.Errorf("fee rule: bps %d out of range", rule.Bps))
+		}
+		for _, amount := range []string{rule.Min, rule.Max, rule.Flat} {
+			if _, err := types.ParseBGL(amount); amount != "" && err != nil {
+				processError(fmt.Errorf("fee rule: %v", err))
+			}
+		}
+	}
+}
+
+func initLimits(cfg *Configuration) {
+	switch cfg.Limits.BelowMin {
+	case "":
+		cfg.Limits.BelowMin = "hold"
+	case "hold", "return":
+	default:
+		processError(fmt.Errorf("unknown below_min policy %q", cfg.Limits.BelowMin))
+	}
+
+	for _, rule := range cfg.Limits.Rules {
+		checkRoute("limit rule", rule.Direction, rule.Chain)
+		min, err := types.ParseBGL(rule.Min)
+		if rule.Min != "" && err != nil {
+			processError(fmt.Errorf("limit rule: %v", err))
+		}
+		max, err := types.ParseBGL(rule.Max)
+		if rule.Max != "" && err != nil {
+			processError(fmt.Errorf("limit rule: %v", err))
+		}
+		if rule.Min != "" && rule.Max != "" && min.Cmp(max) > 0 {
+			processError(fmt.Errorf("limit rule: min %s above max %s", rule.Min, rule.Max))
+		}
+	}
+}
+
+func initCaps(cfg *Configuration) {
+	for _, rule := range cfg.Caps {
+		checkRoute("cap rule", rule.Direction, rule.Chain)
+		for _, amount := range []string{rule.Hourly, rule.Daily} {
+			if _, err := types.ParseBGL(amount); amount != "" && err != nil {
+				processError(fmt.Errorf("cap rule: %v", err))
+			}
+		}
+	}
+}
+
+// direction and chain code of a rule, empty matches all
+func checkRoute(what string, direction string, chain string) {
+	if direction != "" && direction != DirectionBGLToWBGL && direction != DirectionWBGLToBGL {
+		processError(fmt.Errorf("%s: unknown direction %q", what, direction))
+	}
+	if _, ok := ChainByCode(chain); chain != "" && !ok {
+		processError(fmt.Errorf("%s: unknown chain %q", what, chain))
+	}
+}
+
+func initDefaults(cfg *Configuration) {
+	if cfg.EVM.MaxRetries == 0 {
+		cfg.EVM.MaxRetries = 3
+	}
+	if cfg.EVM.DropTimeout == 0 {
+		cfg.EVM.DropTimeout = 30
+	}
+	if cfg.Server.AdminURL == "" {
+		cfg.Server.AdminURL = "http://localhost:8080"
+		if cfg.Server.UseSSL {
+			cfg.Server.AdminURL = "https://localhost"
+		}
+	}
+	cfg.Server.AdminURL = strings.TrimRight(cfg.Server.AdminURL, "/")
+	switch cfg.Dust.Policy {
+	case "":
+		cfg.Dust.Policy = "revenue"
+	case "revenue", "refund":
+	default:
+		processError(fmt.Errorf("unknown dust policy %q", cfg.Dust.Policy))
+	}
+	// BGL can not carry less than a satoshi
+	if cfg.Dust.RefundThreshold < 1e10 {
+		cfg.Dust.RefundThreshold = 1e10
+	}
+}
+
 func Init() {
 	readFile(&Config)
 	readEnv(&Config)
+	initDefaults(&Config)
+	initChains(&Config)
+	initFees(&Config)
+	initLimits(&Config)
+	initCaps(&Config)
 }
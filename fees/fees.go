@@ -0,0 +1,76 @@
+package fees
+
+import (
+	"strconv"
+	"strings"
+
+	"gobglbridge/config"
+	"gobglbridge/types"
+)
+
+// Quote is the fee of a bridge transfer and what the recipient gets
+type Quote struct {
+	Amount  types.Amount
+	Fee     types.Amount
+	Receive types.Amount // zero if fee takes it all
+	Rule    config.FeeRule
+}
+
+// fee rule for direction and EVM chain: the most specific one, first wins on tie
+func Rule(direction string, chainId int) config.FeeRule {
+	code := config.EVMChains[chainId].ShortCode
+
+	best, bestScore := config.FeeRule{}, -1
+	for _, rule := range config.Config.Fees {
+		if score := match(rule.Direction, rule.Chain, direction, code); score > bestScore {
+			best, bestScore = rule, score
+		}
+	}
+	return best
+}
+
+// how specific rule route is: chain outweighs direction, -1 if it does not match
+func match(ruleDirection string, ruleChain string, direction string, code string) int {
+	if (ruleDirection != "" && ruleDirection != direction) || (ruleChain != "" && !strings.EqualFold(ruleChain, code)) {
+		return -1
+	}
+	score := 0
+	if ruleChain != "" {
+		score += 2
+	}
+	if ruleDirection != "" {
+		score++
+	}
+	return score
+}
+
+// fee of transferring amount, chainId is the EVM side of the transfer
+func Compute(direction string, chainId int, amount types.Amount) Quote {
+	rule := Rule(direction, chainId)
+
+	fee := amount.MulDiv(rule.Bps, 10000)
+	if min := parse(rule.Min); fee.Cmp(min) < 0 {
+		fee = min
+	}
+	if max := parse(rule.Max); rule.Max != "" && fee.Cmp(max) > 0 {
+		fee = max
+	}
+	fee = fee.Add(parse(rule.Flat))
+
+	receive := types.Amount{}
+	if fee.Cmp(amount) < 0 {
+		receive = amount.Sub(fee)
+	}
+	return Quote{Amount: amount, Fee: fee, Receive: receive, Rule: rule}
+}
+
+// percentage part of the rule, e.g. "0.75"
+func Percentage(rule config.FeeRule) string {
+	return strconv.FormatFloat(float64(rule.Bps)/100, 'f', -1, 64)
+}
+
+// rule amounts are validated by config.Init, empty is zero
+func parse(bgl string) types.Amount {
+	amount, _ := types.ParseBGL(bgl)
+	return amount
+}
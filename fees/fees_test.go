@@ -0,0 +1,99 @@
+package fees
+
+import (
+	"testing"
+
+	"gobglbridge/config"
+	"gobglbridge/types"
+)
+
+// chains 1 (eth) and 56 (bsc), restored after test
+func setChains(t *testing.T) {
+	t.Helper()
+	chains, cfg := config.EVMChains, config.Config
+	t.Cleanup(func() { config.EVMChains, config.Config = chains, cfg })
+	config.EVMChains = map[int]config.ChainConfig{
+		1:  {Name: "Ethereum", ShortCode: "eth", ChainID: 1},
+		56: {Name: "BSC", ShortCode: "bsc", ChainID: 56},
+	}
+}
+
+func bgl(t *testing.T, s string) types.Amount {
+	t.Helper()
+	amount, err := types.ParseBGL(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return amount
+}
+
+func TestRulePrecedence(t *testing.T) {
+	setChains(t)
+	config.Config.Fees = []config.FeeRule{
+		{Bps: 1},
+		{Direction: config.DirectionBGLToWBGL, Bps: 2},
+		{Chain: "bsc", Bps: 3},
+		{Direction: config.DirectionWBGLToBGL, Chain: "ETH", Bps: 4},
+		// same route as the previous one, first wins
+		{Direction: config.DirectionWBGLToBGL, Chain: "eth", Bps: 5},
+	}
+
+	tests := []struct {
+		direction string
+		chainId   int
+		bps       int64
+	}{
+		{config.DirectionWBGLToBGL, 1, 4},   // direction and chain
+		{config.DirectionBGLToWBGL, 56, 3},  // chain outweighs direction
+		{config.DirectionBGLToWBGL, 1, 2},   // direction only
+		{config.DirectionWBGLToBGL, 56, 3},  // chain only
+		{config.DirectionWBGLToBGL, 137, 1}, // unknown chain gets the catch-all
+	}
+	for _, tt := range tests {
+		if rule := Rule(tt.direction, tt.chainId); rule.Bps != tt.bps {
+			t.Errorf("Rule(%s, %d) = %d bps, want %d", tt.direction, tt.chainId, rule.Bps, tt.bps)
+		}
+	}
+
+	config.Config.Fees = nil
+	if rule := Rule(config.DirectionBGLToWBGL, 1); rule != (config.FeeRule{}) {
+		t.Errorf("Rule() without rules = %+v", rule)
+	}
+}
+
+func TestCompute(t *testing.T) {
+	setChains(t)
+
+	tests := []struct {
+		rule    config.FeeRule
+		amount  string
+		fee     string
+		receive string
+	}{
+		{config.FeeRule{}, "100", "0.00000000", "100.00000000"},
+		{config.FeeRule{Bps: 75}, "100", "0.75000000", "99.25000000"},
+		{config.FeeRule{Bps: 75, Min: "1"}, "100", "1.00000000", "99.00000000"},
+		{config.FeeRule{Bps: 75, Max: "0.5"}, "100", "0.50000000", "99.50000000"},
+		{config.FeeRule{Bps: 75, Max: "0.5", Flat: "2"}, "100", "2.50000000", "97.50000000"},
+		{config.FeeRule{Bps: 1}, "1", "0.00010000", "0.99990000"},
+		// fee takes it all
+		{config.FeeRule{Flat: "5"}, "5", "5.00000000", "0.00000000"},
+		{config.FeeRule{Min: "10"}, "5", "10.00000000", "0.00000000"},
+	}
+	for _, tt := range tests {
+		config.Config.Fees = []config.FeeRule{tt.rule}
+		quote := Compute(config.DirectionBGLToWBGL, 1, bgl(t, tt.amount))
+		if quote.Fee.BGLString() != tt.fee || quote.Receive.BGLString() != tt.receive {
+			t.Errorf("Compute(%s) with %+v = fee %s, receive %s, want %s, %s",
+				tt.amount, tt.rule, quote.Fee.BGLString(), quote.Receive.BGLString(), tt.fee, tt.receive)
+		}
+	}
+}
+
+func TestPercentage(t *testing.T) {
+	for bps, want := range map[int64]string{0: "0", 75: "0.75", 100: "1", 250: "2.5"} {
+		if got := Percentage(config.FeeRule{Bps: bps}); got != want {
+			t.Errorf("Percentage(%d bps) = %s, want %s", bps, got, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package fees
+
+import (
+	"fmt"
+
+	"gobglbridge/config"
+	"gobglbridge/types"
+)
+
+// Limits are deposit limits of a route, zero Max is no maximum
+type Limits struct {
+	Min  types.Amount
+	Max  types.Amount
+	Rule config.LimitRule
+}
+
+// limits for direction and EVM chain: the most specific rule, first wins on tie
+func Limit(direction string, chainId int) Limits {
+	code := config.EVMChains[chainId].ShortCode
+
+	best, bestScore := config.LimitRule{}, -1
+	for _, rule := range config.Config.Limits.Rules {
+		if score := match(rule.Direction, rule.Chain, direction, code); score > bestScore {
+			best, bestScore = rule, score
+		}
+	}
+	return Limits{Min: parse(best.Min), Max: parse(best.Max), Rule: best}
+}
+
+// Caps are payout volume caps of a route, Rule tells which are set
+type Caps struct {
+	Hourly types.Amount
+	Daily  types.Amount
+	Rule   config.CapRule
+}
+
+// caps for direction and EVM chain: the most specific rule, first wins on tie
+func Cap(direction string, chainId int) Caps {
+	code := config.EVMChains[chainId].ShortCode
+
+	best, bestScore := config.CapRule{}, -1
+	for _, rule := range config.Config.Caps {
+		if score := match(rule.Direction, rule.Chain, direction, code); score > bestScore {
+			best, bestScore = rule, score
+		}
+	}
+	return Caps{Hourly: parse(best.Hourly), Daily: parse(best.Daily), Rule: best}
+}
+
+func (l Limits) Below(amount types.Amount) bool {
+	return amount.Cmp(l.Min) < 0
+}
+
+func (l Limits) Above(amount types.Amount) bool {
+	return l.Rule.Max != "" && amount.Cmp(l.Max) > 0
+}
+
+// why amount of quote is too small to bridge: below minimum or the fee takes it all, empty if it is not
+func (l Limits) TooSmall(quote Quote) string {
+	if l.Below(quote.Amount) {
+		return fmt.Sprintf("below minimum %s", l.Min)
+	}
+	if quote.Receive.IsZero() {
+		return fmt.Sprintf("does not cover bridge fee %s", quote.Fee)
+	}
+	return ""
+}
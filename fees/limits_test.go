@@ -0,0 +1,80 @@
+package fees
+
+import (
+	"testing"
+
+	"gobglbridge/config"
+)
+
+func TestLimit(t *testing.T) {
+	setChains(t)
+	config.Config.Limits.Rules = []config.LimitRule{
+		{Min: "1"},
+		{Direction: config.DirectionWBGLToBGL, Chain: "eth", Min: "100", Max: "10000"},
+		{Chain: "bsc", Max: "500"},
+	}
+
+	tests := []struct {
+		direction string
+		chainId   int
+		amount    string
+		below     bool
+		above     bool
+	}{
+		{config.DirectionBGLToWBGL, 1, "0.99999999", true, false},
+		{config.DirectionBGLToWBGL, 1, "1", false, false},
+		// no maximum in catch-all rule
+		{config.DirectionBGLToWBGL, 1, "1000000", false, false},
+		{config.DirectionWBGLToBGL, 1, "99.99999999", true, false},
+		{config.DirectionWBGLToBGL, 1, "10000", false, false},
+		{config.DirectionWBGLToBGL, 1, "10000.00000001", false, true},
+		// bsc rule has no minimum
+		{config.DirectionBGLToWBGL, 56, "0.00000001", false, false},
+		{config.DirectionWBGLToBGL, 56, "500.1", false, true},
+	}
+	for _, tt := range tests {
+		limits := Limit(tt.direction, tt.chainId)
+		amount := bgl(t, tt.amount)
+		if limits.Below(amount) != tt.below || limits.Above(amount) != tt.above {
+			t.Errorf("%s on %d: %s below %v, above %v, want %v, %v",
+				tt.direction, tt.chainId, tt.amount, limits.Below(amount), limits.Above(amount), tt.below, tt.above)
+		}
+	}
+
+	config.Config.Limits.Rules = nil
+	limits := Limit(config.DirectionBGLToWBGL, 1)
+	if limits.Below(bgl(t, "0")) || limits.Above(bgl(t, "21000000")) {
+		t.Errorf("limits without rules = %+v", limits.Rule)
+	}
+}
+
+func TestCap(t *testing.T) {
+	setChains(t)
+	config.Config.Caps = []config.CapRule{
+		{Daily: "1000"},
+		{Direction: config.DirectionBGLToWBGL, Chain: "eth", Hourly: "10"},
+	}
+
+	caps := Cap(config.DirectionBGLToWBGL, 1)
+	if caps.Rule.Hourly != "10" || caps.Rule.Daily != "" || caps.Hourly.Cmp(bgl(t, "10")) != 0 {
+		t.Errorf("Cap(bgl_to_wbgl, eth) = %+v", caps.Rule)
+	}
+	caps = Cap(config.DirectionWBGLToBGL, 1)
+	if caps.Rule.Daily != "1000" || caps.Rule.Hourly != "" {
+		t.Errorf("Cap(wbgl_to_bgl, eth) = %+v", caps.Rule)
+	}
+}
+
+func TestTooSmall(t *testing.T) {
+	setChains(t)
+	config.Config.Limits.Rules = []config.LimitRule{{Min: "1"}}
+	config.Config.Fees = []config.FeeRule{{Flat: "2"}}
+	limits := Limit(config.DirectionBGLToWBGL, 1)
+
+	for amount, small := range map[string]bool{"0.5": true, "1": true, "2": true, "2.00000001": false} {
+		quote := Compute(config.DirectionBGLToWBGL, 1, bgl(t, amount))
+		if got := limits.TooSmall(quote); (got != "") != small {
+			t.Errorf("TooSmall(%s) = %q, want too small %v", amount, got, small)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"gobglbridge/config"
+	"gobglbridge/store"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// exposed on /metrics in Prometheus format
+
+var (
+	EVMScanLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bridge_evm_scan_lag_blocks",
+		Help: "Latest EVM block minus last scanned block checkpoint.",
+	}, []string{"chain"})
+
+	Executions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bridge_executions_total",
+		Help: "Pending bridge operations processed by executor, by direction and resulting status.",
+	}, []string{"direction", "status"})
+
+	ExecutionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bridge_execution_duration_seconds",
+		Help:    "Time to process a pending bridge operation, by direction.",
+		Buckets: []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"direction"})
+
+	EVMRPCErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bridge_evm_rpc_errors_total",
+		// index in chain's rpc list, URLs often carry provider API keys and /metrics is public
+		Help: "Failed EVM RPC calls, by chain and endpoint index in config rpc list.",
+	}, []string{"chain", "rpc"})
+
+	RoutePaused = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bridge_route_paused",
+		Help: "1 if payouts of a route are paused by volume cap, by direction and chain.",
+	}, []string{"direction", "chain"})
+
+	CapTrips = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bridge_cap_trips_total",
+		Help: "Payouts paused by volume cap, by direction and chain.",
+	}, []string{"direction", "chain"})
+
+	CustodianBalance = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bridge_custodian_balance",
+		Help: "Custodian wallet balance in whole coins, BGL or WBGL per chain.",
+	}, []string{"asset", "chain"})
+)
+
+// executor directions label values
+const (
+	DirectionBGLToWBGL = config.DirectionBGLToWBGL
+	DirectionWBGLToBGL = config.DirectionWBGLToBGL
+)
+
+var lastBGLScan atomic.Int64
+
+func init() {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "bridge_bgl_scan_age_seconds",
+		Help: "Seconds since BGL blocks were last scanned successfully.",
+	}, func() float64 {
+		ts := lastBGLScan.Load()
+		if ts == 0 {
+			return -1
+		}
+		return time.Since(time.Unix(ts, 0)).Seconds()
+	})
+
+	prometheus.MustRegister(statusCollector{})
+}
+
+func BGLScanned() {
+	lastBGLScan.Store(time.Now().Unix())
+}
+
+func ChainName(chainId int) string {
+	return config.EVMChains[chainId].Name
+}
+
+// operation counts are read from storage at scrape time
+type statusCollector struct{}
+
+var statusDesc = prometheus.NewDesc(
+	"bridge_operations",
+	"Number of bridge operations per status.",
+	[]string{"status"}, nil,
+)
+
+func (statusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- statusDesc
+}
+
+func (statusCollector) Collect(ch chan<- prometheus.Metric) {
+	if store.DB == nil {
+		return
+	}
+	for status := range config.RedisStatusSets {
+		count, err := store.DB.CountBridgeOperationsByStatus(status)
+		if err != nil {
+			log.Printf("Error counting %s bridge operations: %v", status, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(statusDesc, prometheus.GaugeValue, float64(count), status)
+	}
+}
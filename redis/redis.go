@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,7 +17,10 @@ import (
 	"github.com/google/uuid"
 )
 
-var pool *redis.Pool
+// Store is the Redis-backed persistence engine
+type Store struct {
+	pool *redis.Pool
+}
 
 func timeoutDialOptions() []redis.DialOption {
 	return []redis.DialOption{
@@ -26,16 +30,22 @@ func timeoutDialOptions() []redis.DialOption {
 	}
 }
 
-func Init() {
-	redisAddr := fmt.Sprintf("%s:%d", config.Config.Server.RedisHost, config.Config.Server.RedisPort)
-	pool = &redis.Pool{
-		MaxIdle: 5,
-		Dial:    func() (redis.Conn, error) { return redis.Dial("tcp", redisAddr, timeoutDialOptions()...) },
+func New(host string, port int) *Store {
+	redisAddr := fmt.Sprintf("%s:%d", host, port)
+	return &Store{
+		pool: &redis.Pool{
+			MaxIdle: 5,
+			Dial:    func() (redis.Conn, error) { return redis.Dial("tcp", redisAddr, timeoutDialOptions()...) },
+		},
 	}
 }
 
-func GetBGLScannedBlock() (string, error) {
-	conn := pool.Get()
+func (s *Store) Close() error {
+	return s.pool.Close()
+}
+
+func (s *Store) GetBGLScannedBlock() (string, error) {
+	conn := s.pool.Get()
 	defer conn.Close()
 
 	blockHash, err := redis.String(conn.Do("GET", "BGLBlockHash"))
@@ -51,8 +61,8 @@ func GetBGLScannedBlock() (string, error) {
 	return "", err
 }
 
-func SetBGLScannedBlock(blockHash string) error {
-	conn := pool.Get()
+func (s *Store) SetBGLScannedBlock(blockHash string) error {
+	conn := s.pool.Get()
 	defer conn.Close()
 
 	_, err := conn.Do("SET", "BGLBlockHash", blockHash)
@@ -64,8 +74,35 @@ func SetBGLScannedBlock(blockHash string) error {
 	return nil
 }
 
-func GetEVMScannedBlock(chainID int) (int, error) {
-	conn := pool.Get()
+// KEYS: checkpoint key
+// ARGV: expected, new, value if missing
+var swapCheckpointScript = redis.NewScript(1, `
+local value = redis.call('GET', KEYS[1]) or ARGV[3]
+if value ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2])
+return 1
+`)
+
+func (s *Store) swapCheckpoint(key string, old, new, missing string) (bool, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	swapped, err := redis.Bool(swapCheckpointScript.Do(conn, key, old, new, missing))
+	if err != nil {
+		log.Printf("error Redis checkpoint swap: %s", err.Error())
+		return false, err
+	}
+	return swapped, nil
+}
+
+func (s *Store) SwapBGLScannedBlock(old, new string) (bool, error) {
+	return s.swapCheckpoint("BGLBlockHash", old, new, "")
+}
+
+func (s *Store) GetEVMScannedBlock(chainID int) (int, error) {
+	conn := s.pool.Get()
 	defer conn.Close()
 
 	blockHeight, err := redis.Int(conn.Do("GET", fmt.Sprintf("chainBlockScanned:%d", chainID)))
@@ -81,8 +118,8 @@ func GetEVMScannedBlock(chainID int) (int, error) {
 	return -1, err
 }
 
-func SetEVMScannedBlock(chainID int, blockHeight int) error {
-	conn := pool.Get()
+func (s *Store) SetEVMScannedBlock(chainID int, blockHeight int) error {
+	conn := s.pool.Get()
 	defer conn.Close()
 
 	_, err := conn.Do("SET", fmt.Sprintf("chainBlockScanned:%d", chainID), blockHeight)
@@ -94,9 +131,316 @@ func SetEVMScannedBlock(chainID int, blockHeight int) error {
 	return nil
 }
 
+func (s *Store) SwapEVMScannedBlock(chainID int, old, new int) (bool, error) {
+	return s.swapCheckpoint(fmt.Sprintf("chainBlockScanned:%d", chainID), strconv.Itoa(old), strconv.Itoa(new), "-1")
+}
+
+func dustLedgerKey(chainID int) string {
+	return fmt.Sprintf("dustledger:%d", chainID)
+}
+
+func (s *Store) AddDust(chainID int, account string, wei int64) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("HINCRBY", dustLedgerKey(chainID), account, wei)
+	if err != nil {
+		log.Printf("error Redis hincrby: %s", err.Error())
+	}
+	return err
+}
+
+func (s *Store) GetDustLedger(chainID int) (map[string]int64, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	ledger, err := redis.Int64Map(conn.Do("HGETALL", dustLedgerKey(chainID)))
+	if err != nil {
+		log.Printf("error Redis hgetall: %s", err.Error())
+		return nil, err
+	}
+	return ledger, nil
+}
+
+const auditLogKey = "auditlog"
+
+func (s *Store) AppendAudit(entry *types.AuditEntry) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("cannot marshal audit entry to JSON: %s", err.Error())
+	}
+
+	_, err = conn.Do("LPUSH", auditLogKey, entryJSON)
+	if err != nil {
+		log.Printf("error Redis lpush: %s", err.Error())
+	}
+	return err
+}
+
+func (s *Store) GetAuditLog(limit int) ([]*types.AuditEntry, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	values, err := redis.ByteSlices(conn.Do("LRANGE", auditLogKey, 0, limit-1))
+	if err != nil {
+		log.Printf("error Redis lrange: %s", err.Error())
+		return nil, err
+	}
+
+	entries := make([]*types.AuditEntry, 0, len(values))
+	for _, value := range values {
+		var entry types.AuditEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal audit entry: %s", err.Error())
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+// route payouts, op ID -> "<ts>:<wei>"
+func outflowKey(route string) string {
+	return "outflow:" + route
+}
+
+func parseOutflow(entry string) (int64, types.Amount, error) {
+	ts, wei, _ := strings.Cut(entry, ":")
+	tsInt, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return 0, types.Amount{}, err
+	}
+	amount, err := types.ParseWei(wei)
+	return tsInt, amount, err
+}
+
+func (s *Store) AddOutflow(route string, opID string, ts int64, wei string) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("HSET", outflowKey(route), opID, fmt.Sprintf("%d:%s", ts, wei))
+	if err != nil {
+		log.Printf("error Redis hset: %s", err.Error())
+	}
+	return err
+}
+
+func (s *Store) GetOutflow(route string, since int64) (types.Amount, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	entries, err := redis.StringMap(conn.Do("HGETALL", outflowKey(route)))
+	if err != nil {
+		log.Printf("error Redis hgetall: %s", err.Error())
+		return types.Amount{}, err
+	}
+
+	total := types.Amount{}
+	for opID, entry := range entries {
+		ts, amount, err := parseOutflow(entry)
+		if err != nil {
+			return types.Amount{}, fmt.Errorf("outflow of %s: %w", opID, err)
+		}
+		if ts >= since {
+			total = total.Add(amount)
+		}
+	}
+	return total, nil
+}
+
+func (s *Store) PruneOutflow(route string, before int64) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	entries, err := redis.StringMap(conn.Do("HGETALL", outflowKey(route)))
+	if err != nil {
+		log.Printf("error Redis hgetall: %s", err.Error())
+		return err
+	}
+
+	for opID, entry := range entries {
+		ts, _, err := parseOutflow(entry)
+		if err == nil && ts >= before {
+			continue
+		}
+		_, err = conn.Do("HDEL", outflowKey(route), opID)
+		if err != nil {
+			log.Printf("error Redis hdel: %s", err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+func nonceKey(chainID int) string {
+	return fmt.Sprintf("evmNonce:%d", chainID)
+}
+
+func (s *Store) GetEVMNonce(chainID int) (int64, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	nonce, err := redis.Int64(conn.Do("GET", nonceKey(chainID)))
+	if errors.Is(err, redis.ErrNil) {
+		return -1, nil
+	}
+	if err != nil {
+		log.Printf("error Redis get: %s", err.Error())
+		return -1, err
+	}
+	return nonce, nil
+}
+
+var reserveNonceScript = redis.NewScript(1, `
+local nonce = redis.call('GET', KEYS[1])
+if not nonce then
+	return -1
+end
+redis.call('INCR', KEYS[1])
+return tonumber(nonce)
+`)
+
+func (s *Store) ReserveEVMNonce(chainID int) (int64, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	nonce, err := redis.Int64(reserveNonceScript.Do(conn, nonceKey(chainID)))
+	if err != nil {
+		log.Printf("error Redis nonce reserve: %s", err.Error())
+		return -1, err
+	}
+	if nonce == -1 {
+		return -1, fmt.Errorf("nonce of chain %d is not initialized", chainID)
+	}
+	return nonce, nil
+}
+
+// ARGV: expected (-1 if missing), new
+var swapNonceScript = redis.NewScript(1, `
+local nonce = tonumber(redis.call('GET', KEYS[1]) or '-1')
+if nonce ~= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2])
+return 1
+`)
+
+func (s *Store) SwapEVMNonce(chainID int, old, new int64) (bool, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	swapped, err := redis.Bool(swapNonceScript.Do(conn, nonceKey(chainID), old, new))
+	if err != nil {
+		log.Printf("error Redis nonce swap: %s", err.Error())
+		return false, err
+	}
+	return swapped, nil
+}
+
+// moves operation from its previous status set into the new one, atomically
+// and only if the operation is still in prevStatus (compare-and-set)
+// KEYS: prev status set, prev record key, new status set, new record key,
+// source tx hash index, destination tx hash index,
+// source address index, destination address index, binding index (index keys are empty to skip)
+// ARGV: operation JSON, operation ID
+var changeStatusScript = redis.NewScript(9, `
+if redis.call("SREM", KEYS[1], KEYS[2]) == 0 then
+	return 0
+end
+redis.call("DEL", KEYS[2])
+redis.call("SET", KEYS[4], ARGV[1])
+redis.call("SADD", KEYS[3], KEYS[4])
+for i = 5, 6 do
+	if KEYS[i] ~= "" then
+		redis.call("SET", KEYS[i], ARGV[2])
+	end
+end
+for i = 7, 9 do
+	if KEYS[i] ~= "" then
+		redis.call("SADD", KEYS[i], ARGV[2])
+	end
+end
+return 1
+`)
+
+// moves finished operation out of its status set into monthly archive hash,
+// only if the operation is still in the same status
+// KEYS: status set, record key, archive partition hash, archive index hash
+// ARGV: operation ID, operation JSON, archive partition hash name
+var archiveScript = redis.NewScript(4, `
+if redis.call("SREM", KEYS[1], KEYS[2]) == 0 then
+	return 0
+end
+redis.call("DEL", KEYS[2])
+redis.call("HSET", KEYS[3], ARGV[1], ARGV[2])
+redis.call("HSET", KEYS[4], ARGV[1], ARGV[3])
+return 1
+`)
+
+// archived operations are kept per month in bridgeoparchive:<YYYY-MM> hashes,
+// bridgeoparchive:index maps operation ID to its partition
+const archiveIndexKey = "bridgeoparchive:index"
+
+// tx hash -> operation ID indexes, so lookups do not scan every status set,
+// address or binding ID -> SET of operation IDs
+const (
+	indexSourceTx = "srctx"
+	indexDestTx   = "desttx"
+	indexAddress  = "addr"
+	indexBinding  = "binding"
+	// version of indexes built for records created before indexing existed
+	indexVersionKey = "bridgeopidx:version"
+	indexVersion    = 2
+)
+
+func txIndexKey(index string, txHash string) string {
+	if txHash == "" {
+		return ""
+	}
+	return fmt.Sprintf("bridgeopidx:%s:%s", index, strings.ToLower(txHash))
+}
+
+func addressIndexKey(address string) string {
+	if address == "" {
+		return ""
+	}
+	return fmt.Sprintf("bridgeopidx:%s:%s", indexAddress, strings.ToLower(address))
+}
+
+func bindingIndexKey(bindingID string) string {
+	if bindingID == "" {
+		return ""
+	}
+	return fmt.Sprintf("bridgeopidx:%s:%s", indexBinding, bindingID)
+}
+
+// queues index updates for operation on connection (inside MULTI or pipeline)
+func sendIndexes(conn redis.Conn, op *types.BridgeOperation) {
+	if op.SourceTxHash != "" {
+		conn.Send("SET", txIndexKey(indexSourceTx, op.SourceTxHash), op.ID)
+	}
+	if op.DestTxHash != "" {
+		conn.Send("SET", txIndexKey(indexDestTx, op.DestTxHash), op.ID)
+	}
+	for _, txHash := range op.ReplacedTxHashes {
+		conn.Send("SET", txIndexKey(indexDestTx, txHash), op.ID)
+	}
+	if op.SourceAddress != "" {
+		conn.Send("SADD", addressIndexKey(op.SourceAddress), op.ID)
+	}
+	if op.DestAddress != "" {
+		conn.Send("SADD", addressIndexKey(op.DestAddress), op.ID)
+	}
+	if op.BindingID != "" {
+		conn.Send("SADD", bindingIndexKey(op.BindingID), op.ID)
+	}
+}
+
 // note that multiple sets should not contain one operation
-func UpsertBridgeOperation(op *types.BridgeOperation) error {
-	conn := pool.Get()
+func (s *Store) UpsertBridgeOperation(op *types.BridgeOperation) error {
+	conn := s.pool.Get()
 	defer conn.Close()
 
 	if op == nil {
@@ -107,9 +451,14 @@ func UpsertBridgeOperation(op *types.BridgeOperation) error {
 		return errors.New("bridge operation cannot have empty status")
 	}
 
+	if _, ok := config.RedisStatusSets[op.Status]; !ok {
+		return fmt.Errorf("unknown bridge operation status %s", op.Status)
+	}
+
 	if op.ID == "" {
 		op.ID = uuid.New().String()
 	}
+	op.TsUpdated = time.Now().Unix()
 	recordKey := fmt.Sprintf("bridgeop:%s:%s", op.Status, op.ID)
 
 	opJSON, err := json.Marshal(op)
@@ -117,24 +466,24 @@ func UpsertBridgeOperation(op *types.BridgeOperation) error {
 		return fmt.Errorf("cannot marshal bridge operation to JSON: %s", err.Error())
 	}
 
-	_, err = conn.Do("SET", recordKey, opJSON)
+	// record, its status SET entry and indexes are written in one transaction
+	conn.Send("MULTI")
+	conn.Send("SET", recordKey, opJSON)
+	conn.Send("SADD", config.RedisStatusSets[op.Status], recordKey)
+	sendIndexes(conn, op)
+	_, err = conn.Do("EXEC")
 	if err != nil {
-		log.Printf("error Redis SET: %s", err.Error())
-		return err
-	}
-
-	// also add the key to the corresponding SET
-	_, err = conn.Do("SADD", config.RedisStatusSets[op.Status], recordKey)
-	if err != nil {
-		log.Printf("error Redis SET: %s", err.Error())
+		log.Printf("error Redis EXEC: %s", err.Error())
 		return err
 	}
 
 	return nil
 }
 
-func ChangeBridgeOperationStatus(op *types.BridgeOperation, prevStatus string) error {
-	conn := pool.Get()
+// returns types.ErrStatusConflict if operation is not in prevStatus anymore
+// (e.g. was already picked up by another executor), nothing is changed then
+func (s *Store) ChangeBridgeOperationStatus(op *types.BridgeOperation, prevStatus string) error {
+	conn := s.pool.Get()
 	defer conn.Close()
 
 	if op == nil {
@@ -146,9 +495,18 @@ func ChangeBridgeOperationStatus(op *types.BridgeOperation, prevStatus string) e
 	}
 
 	if op.ID == "" {
-		op.ID = uuid.New().String()
+		return errors.New("bridge operation without ID cannot change status")
 	}
 
+	if _, ok := config.RedisStatusSets[op.Status]; !ok {
+		return fmt.Errorf("unknown bridge operation status %s", op.Status)
+	}
+
+	if _, ok := config.RedisStatusSets[prevStatus]; !ok {
+		return fmt.Errorf("unknown bridge operation status %s", prevStatus)
+	}
+
+	op.TsUpdated = time.Now().Unix()
 	prevRecordKey := fmt.Sprintf("bridgeop:%s:%s", prevStatus, op.ID)
 	recordKey := fmt.Sprintf("bridgeop:%s:%s", op.Status, op.ID)
 
@@ -157,62 +515,271 @@ func ChangeBridgeOperationStatus(op *types.BridgeOperation, prevStatus string) e
 		return fmt.Errorf("cannot marshal bridge operation to JSON: %s", err.Error())
 	}
 
-	_, err = conn.Do("SREM", config.RedisStatusSets[prevStatus], prevRecordKey)
+	changed, err := redis.Int(changeStatusScript.Do(
+		conn,
+		config.RedisStatusSets[prevStatus],
+		prevRecordKey,
+		config.RedisStatusSets[op.Status],
+		recordKey,
+		txIndexKey(indexSourceTx, op.SourceTxHash),
+		txIndexKey(indexDestTx, op.DestTxHash),
+		addressIndexKey(op.SourceAddress),
+		addressIndexKey(op.DestAddress),
+		bindingIndexKey(op.BindingID),
+		opJSON,
+		op.ID,
+	))
 	if err != nil {
-		log.Printf("error Redis SREM: %s", err.Error())
+		log.Printf("error Redis EVALSHA: %s", err.Error())
 		return err
 	}
 
-	_, err = conn.Do("DEL", prevRecordKey)
+	if changed == 0 {
+		return fmt.Errorf("%w: operation %s is not %s", types.ErrStatusConflict, op.ID, prevStatus)
+	}
+
+	return nil
+}
+
+func (s *Store) FindBridgeOperationSourceTxHash(txHash string) (*types.BridgeOperation, error) {
+	return s.findBridgeOperationByIndex(indexSourceTx, txHash)
+}
+
+func (s *Store) FindBridgeOperationDestinationTxHash(txHash string) (*types.BridgeOperation, error) {
+	return s.findBridgeOperationByIndex(indexDestTx, txHash)
+}
+
+func (s *Store) findBridgeOperationByIndex(index string, txHash string) (*types.BridgeOperation, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	if txHash == "" {
+		return nil, errors.New("empty search tx hash")
+	}
+
+	opID, err := redis.String(conn.Do("GET", txIndexKey(index, txHash)))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, nil
+	}
 	if err != nil {
-		log.Printf("error Redis DEL: %s", err.Error())
-		return err
+		log.Printf("error Redis GET: %s", err.Error())
+		return nil, err
+	}
+
+	return s.FindBridgeOperationByID(opID)
+}
+
+// record key contains the status, so all possible keys are fetched at once
+func (s *Store) FindBridgeOperationByID(opID string) (*types.BridgeOperation, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	if opID == "" {
+		return nil, errors.New("empty bridge operation ID")
 	}
 
-	_, err = conn.Do("SET", recordKey, opJSON)
+	keys := make([]interface{}, 0, len(config.RedisStatusSets))
+	for status := range config.RedisStatusSets {
+		keys = append(keys, fmt.Sprintf("bridgeop:%s:%s", status, opID))
+	}
+
+	records, err := redis.ByteSlices(conn.Do("MGET", keys...))
 	if err != nil {
-		log.Printf("error Redis SET: %s", err.Error())
-		return err
+		log.Printf("error Redis MGET: %s", err.Error())
+		return nil, err
+	}
+
+	for _, record := range records {
+		if record == nil {
+			continue
+		}
+		var op types.BridgeOperation
+		err = json.Unmarshal(record, &op)
+		if err != nil {
+			return nil, err
+		}
+		return &op, nil
 	}
 
-	_, err = conn.Do("SADD", config.RedisStatusSets[op.Status], recordKey)
+	return s.findArchivedBridgeOperation(opID)
+}
+
+func (s *Store) findArchivedBridgeOperation(opID string) (*types.BridgeOperation, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	partition, err := redis.String(conn.Do("HGET", archiveIndexKey, opID))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("error Redis HGET: %s", err.Error())
+		return nil, err
+	}
+
+	record, err := redis.Bytes(conn.Do("HGET", partition, opID))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("error Redis HGET: %s", err.Error())
+		return nil, err
+	}
+
+	var op types.BridgeOperation
+	err = json.Unmarshal(record, &op)
+	if err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+// moves finished operation from the hot status set into its monthly archive,
+// it stays reachable by ID and tx hash, but is not listed by status anymore
+func (s *Store) ArchiveBridgeOperation(op *types.BridgeOperation) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	if op == nil || op.ID == "" {
+		return errors.New("bridge operation without ID cannot be archived")
+	}
+
+	if _, ok := config.RedisStatusSets[op.Status]; !ok {
+		return fmt.Errorf("unknown bridge operation status %s", op.Status)
+	}
+
+	opJSON, err := json.Marshal(op)
+	if err != nil {
+		return fmt.Errorf("cannot marshal bridge operation to JSON: %s", err.Error())
+	}
+
+	partition := fmt.Sprintf("bridgeoparchive:%s", op.ArchiveMonth())
+	moved, err := redis.Int(archiveScript.Do(
+		conn,
+		config.RedisStatusSets[op.Status],
+		fmt.Sprintf("bridgeop:%s:%s", op.Status, op.ID),
+		partition,
+		archiveIndexKey,
+		op.ID,
+		opJSON,
+		partition,
+	))
 	if err != nil {
-		log.Printf("error Redis SADD: %s", err.Error())
+		log.Printf("error Redis EVALSHA: %s", err.Error())
 		return err
 	}
 
+	if moved == 0 {
+		return fmt.Errorf("%w: operation %s is not %s", types.ErrStatusConflict, op.ID, op.Status)
+	}
+
 	return nil
 }
 
-// Attention, this operation scans everything that is present
-// Older/processed should be moved to another place otherwise performance will degrade (athough O(n) still)
-func FindBridgeOperationSourceTxHash(txHash string) (*types.BridgeOperation, error) {
-	return FindBridgeOperationAllStatuses("SourceTxHash", txHash)
+// all operations where address is either source or destination, archived included
+func (s *Store) FindBridgeOperationsByAddress(address string) ([]*types.BridgeOperation, error) {
+	if address == "" {
+		return nil, errors.New("empty search address")
+	}
+	return s.findBridgeOperationsBySetIndex(addressIndexKey(address))
 }
 
-func FindBridgeOperationDestinationTxHash(txHash string) (*types.BridgeOperation, error) {
-	return FindBridgeOperationAllStatuses("DestTxHash", txHash)
+// all operations created from an address book record (binding), archived included
+func (s *Store) FindBridgeOperationsByBinding(bindingID string) ([]*types.BridgeOperation, error) {
+	if bindingID == "" {
+		return nil, errors.New("empty search binding ID")
+	}
+	return s.findBridgeOperationsBySetIndex(bindingIndexKey(bindingID))
 }
 
-func FindBridgeOperationAllStatuses(field string, value string) (*types.BridgeOperation, error) {
-	for status := range config.RedisStatusSets {
-		op, err := FindBridgeOperationByFieldStringValue(field, value, status)
+func (s *Store) findBridgeOperationsBySetIndex(indexKey string) ([]*types.BridgeOperation, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	opIDs, err := redis.Strings(conn.Do("SMEMBERS", indexKey))
+	if err != nil {
+		log.Printf("error Redis SMEMBERS: %s", err.Error())
+		return nil, err
+	}
+
+	ops := make([]*types.BridgeOperation, 0, len(opIDs))
+	for _, opID := range opIDs {
+		op, err := s.FindBridgeOperationByID(opID)
 		if err != nil {
 			return nil, err
 		}
 		if op != nil {
-			return op, nil
+			ops = append(ops, op)
 		}
 	}
-	return nil, nil
+
+	return ops, nil
 }
 
-func FindBridgeOperationStatus(status string) (*types.BridgeOperation, error) {
-	return FindBridgeOperationByFieldStringValue("Status", status, status)
+// builds lookup indexes for operations stored before indexing was introduced
+// (or before the current index version), does nothing if already done
+func (s *Store) EnsureIndexes() error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	version, err := redis.Int(conn.Do("GET", indexVersionKey))
+	if err != nil && !errors.Is(err, redis.ErrNil) {
+		log.Printf("error Redis GET: %s", err.Error())
+		return err
+	}
+	if version >= indexVersion {
+		return nil
+	}
+
+	var ops []*types.BridgeOperation
+	for status := range config.RedisStatusSets {
+		statusOps, err := s.FindAllBridgeOperationsByStatus(status)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, statusOps...)
+	}
+
+	archivedIDs, err := redis.Strings(conn.Do("HKEYS", archiveIndexKey))
+	if err != nil {
+		log.Printf("error Redis HKEYS: %s", err.Error())
+		return err
+	}
+	for _, opID := range archivedIDs {
+		op, err := s.findArchivedBridgeOperation(opID)
+		if err != nil {
+			return err
+		}
+		if op != nil {
+			ops = append(ops, op)
+		}
+	}
+
+	for _, op := range ops {
+		sendIndexes(conn, op)
+	}
+	// flush pipeline and drain replies
+	_, err = conn.Do("")
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Do("SET", indexVersionKey, indexVersion)
+	if err != nil {
+		log.Printf("error Redis SET: %s", err.Error())
+		return err
+	}
+
+	log.Printf("Built lookup indexes for %d bridge operations", len(ops))
+	return nil
 }
 
-func FindBridgeOperationByFieldStringValue(field, value string, status string) (*types.BridgeOperation, error) {
-	conn := pool.Get()
+func (s *Store) FindBridgeOperationStatus(status string) (*types.BridgeOperation, error) {
+	return s.FindBridgeOperationByFieldStringValue("Status", status, status)
+}
+
+func (s *Store) FindBridgeOperationByFieldStringValue(field, value string, status string) (*types.BridgeOperation, error) {
+	conn := s.pool.Get()
 	defer conn.Close()
 
 	if field == "" || value == "" {
@@ -267,8 +834,8 @@ func FindBridgeOperationByFieldStringValue(field, value string, status string) (
 	return nil, nil
 }
 
-func UpsertAddressBookRecord(rec *types.AddressBookRecord) error {
-	conn := pool.Get()
+func (s *Store) UpsertAddressBookRecord(rec *types.AddressBookRecord) error {
+	conn := s.pool.Get()
 	defer conn.Close()
 
 	if rec == nil {
@@ -304,8 +871,8 @@ func UpsertAddressBookRecord(rec *types.AddressBookRecord) error {
 	return nil
 }
 
-func GetAddressBookBySourceAddress(chainType types.ChainType, address string) (*types.AddressBookRecord, error) {
-	conn := pool.Get()
+func (s *Store) GetAddressBookBySourceAddress(chainType types.ChainType, address string) (*types.AddressBookRecord, error) {
+	conn := s.pool.Get()
 	defer conn.Close()
 
 	addrbook, err := redis.Bytes(conn.Do("GET", fmt.Sprintf("addrbook:%d:%s", chainType, strings.ToLower(address))))
@@ -333,8 +900,49 @@ func GetAddressBookBySourceAddress(chainType types.ChainType, address string) (*
 	return &addrbookRecord, nil
 }
 
-func FindAllBridgeOperationsByStatus(status string) ([]*types.BridgeOperation, error) {
-	conn := pool.Get()
+func (s *Store) FindAllAddressBookRecords() ([]*types.AddressBookRecord, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	recs := make([]*types.AddressBookRecord, 0)
+	var cursor int64
+	for {
+		values, err := redis.Values(conn.Do("SCAN", cursor, "MATCH", "addrbook:*", "COUNT", 1000))
+		if err != nil {
+			return nil, err
+		}
+
+		var keys []string
+		_, err = redis.Scan(values, &cursor, &keys)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			value, err := redis.Bytes(conn.Do("GET", key))
+			if errors.Is(err, redis.ErrNil) {
+				continue
+			} else if err != nil {
+				log.Printf("error Redis get: %s", err.Error())
+				return nil, err
+			}
+
+			var rec types.AddressBookRecord
+			if err := json.Unmarshal(value, &rec); err != nil {
+				return nil, err
+			}
+			recs = append(recs, &rec)
+		}
+
+		if cursor == 0 {
+			break
+		}
+	}
+	return recs, nil
+}
+
+func (s *Store) FindAllBridgeOperationsByStatus(status string) ([]*types.BridgeOperation, error) {
+	conn := s.pool.Get()
 	defer conn.Close()
 
 	if _, ok := config.RedisStatusSets[status]; !ok {
@@ -384,3 +992,19 @@ func FindAllBridgeOperationsByStatus(status string) ([]*types.BridgeOperation, e
 
 	return ops, nil
 }
+
+func (s *Store) CountBridgeOperationsByStatus(status string) (int, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	if _, ok := config.RedisStatusSets[status]; !ok {
+		return 0, errors.New("redis key not found for status")
+	}
+
+	count, err := redis.Int(conn.Do("SCARD", config.RedisStatusSets[status]))
+	if err != nil {
+		log.Printf("error Redis SCARD: %s", err.Error())
+		return 0, err
+	}
+	return count, nil
+}
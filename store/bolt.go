@@ -0,0 +1,97 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// single-file embedded backend, runs without a separate Redis process
+type boltBackend struct {
+	db *bolt.DB
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func NewBolt(path string) (Store, error) {
+	if path == "" {
+		return nil, errors.New("storage_path is required for bolt storage")
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &kvStore{db: &boltBackend{db: db}}, nil
+}
+
+func (b *boltBackend) View(fn func(tx kvTx) error) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+func (b *boltBackend) Update(fn func(tx kvTx) error) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+func (b *boltBackend) Close() error {
+	return b.db.Close()
+}
+
+// returned values are copied, bolt memory is only valid inside the transaction
+func (t *boltTx) Get(bucket, key string) []byte {
+	b := t.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+	value := b.Get([]byte(key))
+	if value == nil {
+		return nil
+	}
+	return append([]byte{}, value...)
+}
+
+func (t *boltTx) Put(bucket, key string, value []byte) error {
+	b, err := t.tx.CreateBucketIfNotExists([]byte(bucket))
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), value)
+}
+
+func (t *boltTx) Delete(bucket, key string) error {
+	b := t.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+	return b.Delete([]byte(key))
+}
+
+func (t *boltTx) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	b := t.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+	// collect records first so fn may modify the bucket
+	var keys []string
+	var values [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		keys = append(keys, string(k))
+		values = append(values, append([]byte{}, v...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, key := range keys {
+		if err := fn(key, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
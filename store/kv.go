@@ -0,0 +1,747 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gobglbridge/config"
+	"gobglbridge/types"
+
+	"github.com/google/uuid"
+)
+
+// embedded backends (bolt file, memory) only provide buckets of sorted keys,
+// data layout on top of them is shared
+type kvTx interface {
+	Get(bucket, key string) []byte
+	Put(bucket, key string, value []byte) error
+	Delete(bucket, key string) error
+	// iterates keys in ascending order, stops on first error
+	ForEach(bucket string, fn func(key string, value []byte) error) error
+}
+
+type kvBackend interface {
+	View(fn func(tx kvTx) error) error
+	// changes are applied only if fn returns no error
+	Update(fn func(tx kvTx) error) error
+	Close() error
+}
+
+const (
+	bucketOps         = "ops"           // operation ID -> JSON
+	bucketAddrBook    = "addrbook"      // chainType:address -> JSON
+	bucketCheckpoints = "checkpoints"   // scanners state, custodian nonces
+	bucketSourceTx    = "idx:srctx"     // source tx hash -> operation ID
+	bucketDestTx      = "idx:desttx"    // destination tx hash -> operation ID
+	bucketArchiveIdx  = "archive:index" // archived operation ID -> partition bucket
+)
+
+// operations of an address (source or destination), operation ID -> empty
+func addressBucket(address string) string {
+	return "idx:addr:" + strings.ToLower(address)
+}
+
+// operations created from an address book record, operation ID -> empty
+func bindingBucket(bindingID string) string {
+	return "idx:binding:" + bindingID
+}
+
+// finished operations are archived per month, operation ID -> JSON
+func archiveBucket(month string) string {
+	return "archive:" + month
+}
+
+// status set bucket, operation ID -> empty
+func statusBucket(status string) string {
+	return "status:" + status
+}
+
+// errStop breaks ForEach iteration early
+var errStop = errors.New("stop iteration")
+
+var errReadOnly = errors.New("write in read-only transaction")
+
+type kvStore struct {
+	db kvBackend
+}
+
+func (s *kvStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *kvStore) GetBGLScannedBlock() (string, error) {
+	var blockHash string
+	err := s.db.View(func(tx kvTx) error {
+		blockHash = string(tx.Get(bucketCheckpoints, "BGLBlockHash"))
+		return nil
+	})
+	return blockHash, err
+}
+
+func (s *kvStore) SetBGLScannedBlock(blockHash string) error {
+	return s.db.Update(func(tx kvTx) error {
+		return tx.Put(bucketCheckpoints, "BGLBlockHash", []byte(blockHash))
+	})
+}
+
+func (s *kvStore) SwapBGLScannedBlock(old, new string) (bool, error) {
+	swapped := false
+	err := s.db.Update(func(tx kvTx) error {
+		if string(tx.Get(bucketCheckpoints, "BGLBlockHash")) != old {
+			return nil
+		}
+		swapped = true
+		return tx.Put(bucketCheckpoints, "BGLBlockHash", []byte(new))
+	})
+	return swapped && err == nil, err
+}
+
+func (s *kvStore) GetEVMScannedBlock(chainID int) (int, error) {
+	blockHeight := -1
+	err := s.db.View(func(tx kvTx) error {
+		value := tx.Get(bucketCheckpoints, fmt.Sprintf("chainBlockScanned:%d", chainID))
+		if value == nil {
+			return nil
+		}
+		var err error
+		blockHeight, err = strconv.Atoi(string(value))
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+	return blockHeight, nil
+}
+
+func (s *kvStore) SetEVMScannedBlock(chainID int, blockHeight int) error {
+	return s.db.Update(func(tx kvTx) error {
+		return tx.Put(bucketCheckpoints, fmt.Sprintf("chainBlockScanned:%d", chainID), []byte(strconv.Itoa(blockHeight)))
+	})
+}
+
+func (s *kvStore) SwapEVMScannedBlock(chainID int, old, new int) (bool, error) {
+	key := fmt.Sprintf("chainBlockScanned:%d", chainID)
+	swapped := false
+	err := s.db.Update(func(tx kvTx) error {
+		blockHeight := -1
+		if value := tx.Get(bucketCheckpoints, key); value != nil {
+			var err error
+			blockHeight, err = strconv.Atoi(string(value))
+			if err != nil {
+				return err
+			}
+		}
+		if blockHeight != old {
+			return nil
+		}
+		swapped = true
+		return tx.Put(bucketCheckpoints, key, []byte(strconv.Itoa(new)))
+	})
+	return swapped && err == nil, err
+}
+
+// sub-satoshi dust ledger of an EVM chain, account -> wei
+func dustBucket(chainID int) string {
+	return fmt.Sprintf("dust:%d", chainID)
+}
+
+func (s *kvStore) AddDust(chainID int, account string, wei int64) error {
+	return s.db.Update(func(tx kvTx) error {
+		balance := int64(0)
+		if value := tx.Get(dustBucket(chainID), account); value != nil {
+			var err error
+			balance, err = strconv.ParseInt(string(value), 10, 64)
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Put(dustBucket(chainID), account, []byte(strconv.FormatInt(balance+wei, 10)))
+	})
+}
+
+func (s *kvStore) GetDustLedger(chainID int) (map[string]int64, error) {
+	ledger := map[string]int64{}
+	err := s.db.View(func(tx kvTx) error {
+		return tx.ForEach(dustBucket(chainID), func(account string, value []byte) error {
+			balance, err := strconv.ParseInt(string(value), 10, 64)
+			ledger[account] = balance
+			return err
+		})
+	})
+	return ledger, err
+}
+
+// operator actions, keyed by time so iteration is chronological
+const bucketAudit = "audit"
+
+func (s *kvStore) AppendAudit(entry *types.AuditEntry) error {
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("cannot marshal audit entry to JSON: %s", err.Error())
+	}
+	key := fmt.Sprintf("%020d:%s", time.Now().UnixNano(), uuid.New().String())
+	return s.db.Update(func(tx kvTx) error {
+		return tx.Put(bucketAudit, key, entryJSON)
+	})
+}
+
+func (s *kvStore) GetAuditLog(limit int) ([]*types.AuditEntry, error) {
+	entries := make([]*types.AuditEntry, 0)
+	err := s.db.View(func(tx kvTx) error {
+		return tx.ForEach(bucketAudit, func(_ string, value []byte) error {
+			var entry types.AuditEntry
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return fmt.Errorf("cannot unmarshal audit entry: %s", err.Error())
+			}
+			entries = append(entries, &entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// newest first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// route payouts, op ID -> "<ts>:<wei>"
+func outflowBucket(route string) string {
+	return "outflow:" + route
+}
+
+func parseOutflow(entry []byte) (int64, types.Amount, error) {
+	ts, wei, _ := strings.Cut(string(entry), ":")
+	tsInt, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return 0, types.Amount{}, err
+	}
+	amount, err := types.ParseWei(wei)
+	return tsInt, amount, err
+}
+
+func (s *kvStore) AddOutflow(route string, opID string, ts int64, wei string) error {
+	return s.db.Update(func(tx kvTx) error {
+		return tx.Put(outflowBucket(route), opID, []byte(fmt.Sprintf("%d:%s", ts, wei)))
+	})
+}
+
+func (s *kvStore) GetOutflow(route string, since int64) (types.Amount, error) {
+	total := types.Amount{}
+	err := s.db.View(func(tx kvTx) error {
+		return tx.ForEach(outflowBucket(route), func(opID string, value []byte) error {
+			ts, amount, err := parseOutflow(value)
+			if err != nil {
+				return fmt.Errorf("outflow of %s: %w", opID, err)
+			}
+			if ts >= since {
+				total = total.Add(amount)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return types.Amount{}, err
+	}
+	return total, nil
+}
+
+func (s *kvStore) PruneOutflow(route string, before int64) error {
+	return s.db.Update(func(tx kvTx) error {
+		var old []string
+		err := tx.ForEach(outflowBucket(route), func(opID string, value []byte) error {
+			if ts, _, err := parseOutflow(value); err != nil || ts < before {
+				old = append(old, opID)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, opID := range old {
+			if err := tx.Delete(outflowBucket(route), opID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func nonceKey(chainID int) string {
+	return fmt.Sprintf("evmNonce:%d", chainID)
+}
+
+func getNonce(tx kvTx, chainID int) (int64, error) {
+	value := tx.Get(bucketCheckpoints, nonceKey(chainID))
+	if value == nil {
+		return -1, nil
+	}
+	return strconv.ParseInt(string(value), 10, 64)
+}
+
+func (s *kvStore) GetEVMNonce(chainID int) (int64, error) {
+	nonce := int64(-1)
+	err := s.db.View(func(tx kvTx) error {
+		var err error
+		nonce, err = getNonce(tx, chainID)
+		return err
+	})
+	return nonce, err
+}
+
+func (s *kvStore) ReserveEVMNonce(chainID int) (int64, error) {
+	nonce := int64(-1)
+	err := s.db.Update(func(tx kvTx) error {
+		var err error
+		nonce, err = getNonce(tx, chainID)
+		if err != nil {
+			return err
+		}
+		if nonce == -1 {
+			return fmt.Errorf("nonce of chain %d is not initialized", chainID)
+		}
+		return tx.Put(bucketCheckpoints, nonceKey(chainID), []byte(strconv.FormatInt(nonce+1, 10)))
+	})
+	if err != nil {
+		return -1, err
+	}
+	return nonce, nil
+}
+
+func (s *kvStore) SwapEVMNonce(chainID int, old, new int64) (bool, error) {
+	swapped := false
+	err := s.db.Update(func(tx kvTx) error {
+		nonce, err := getNonce(tx, chainID)
+		if err != nil || nonce != old {
+			return err
+		}
+		swapped = true
+		return tx.Put(bucketCheckpoints, nonceKey(chainID), []byte(strconv.FormatInt(new, 10)))
+	})
+	return swapped && err == nil, err
+}
+
+func getOp(tx kvTx, id string) (*types.BridgeOperation, error) {
+	value := tx.Get(bucketOps, id)
+	if value == nil {
+		return nil, nil
+	}
+	var op types.BridgeOperation
+	err := json.Unmarshal(value, &op)
+	if err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+func putOp(tx kvTx, op *types.BridgeOperation) error {
+	opJSON, err := json.Marshal(op)
+	if err != nil {
+		return fmt.Errorf("cannot marshal bridge operation to JSON: %s", err.Error())
+	}
+	err = tx.Put(bucketOps, op.ID, opJSON)
+	if err != nil {
+		return err
+	}
+	err = putIndexes(tx, op)
+	if err != nil {
+		return err
+	}
+	return tx.Put(statusBucket(op.Status), op.ID, []byte{})
+}
+
+func putIndexes(tx kvTx, op *types.BridgeOperation) error {
+	if op.SourceTxHash != "" {
+		err := tx.Put(bucketSourceTx, strings.ToLower(op.SourceTxHash), []byte(op.ID))
+		if err != nil {
+			return err
+		}
+	}
+	for _, txHash := range append([]string{op.DestTxHash}, op.ReplacedTxHashes...) {
+		if txHash == "" {
+			continue
+		}
+		err := tx.Put(bucketDestTx, strings.ToLower(txHash), []byte(op.ID))
+		if err != nil {
+			return err
+		}
+	}
+	for _, address := range []string{op.SourceAddress, op.DestAddress} {
+		if address == "" {
+			continue
+		}
+		err := tx.Put(addressBucket(address), op.ID, []byte{})
+		if err != nil {
+			return err
+		}
+	}
+	if op.BindingID != "" {
+		err := tx.Put(bindingBucket(op.BindingID), op.ID, []byte{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateOp(op *types.BridgeOperation) error {
+	if op == nil {
+		return errors.New("null object to store")
+	}
+
+	if op.Status == "" {
+		return errors.New("bridge operation cannot have empty status")
+	}
+
+	if _, ok := config.RedisStatusSets[op.Status]; !ok {
+		return fmt.Errorf("unknown bridge operation status %s", op.Status)
+	}
+
+	if op.ID == "" {
+		op.ID = uuid.New().String()
+	}
+	op.TsUpdated = time.Now().Unix()
+	return nil
+}
+
+func (s *kvStore) UpsertBridgeOperation(op *types.BridgeOperation) error {
+	if err := validateOp(op); err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx kvTx) error {
+		return putOp(tx, op)
+	})
+}
+
+// returns types.ErrStatusConflict if operation is not in prevStatus anymore
+func (s *kvStore) ChangeBridgeOperationStatus(op *types.BridgeOperation, prevStatus string) error {
+	if op != nil && op.ID == "" {
+		return errors.New("bridge operation without ID cannot change status")
+	}
+	if err := validateOp(op); err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx kvTx) error {
+		// compare-and-set, operation must still be in prevStatus
+		if tx.Get(statusBucket(prevStatus), op.ID) == nil {
+			return fmt.Errorf("%w: operation %s is not %s", types.ErrStatusConflict, op.ID, prevStatus)
+		}
+		err := tx.Delete(statusBucket(prevStatus), op.ID)
+		if err != nil {
+			return err
+		}
+		return putOp(tx, op)
+	})
+}
+
+func (s *kvStore) FindBridgeOperationSourceTxHash(txHash string) (*types.BridgeOperation, error) {
+	return s.findOpByIndex(bucketSourceTx, txHash)
+}
+
+func (s *kvStore) FindBridgeOperationDestinationTxHash(txHash string) (*types.BridgeOperation, error) {
+	return s.findOpByIndex(bucketDestTx, txHash)
+}
+
+func (s *kvStore) findOpByIndex(index string, txHash string) (*types.BridgeOperation, error) {
+	if txHash == "" {
+		return nil, errors.New("empty search tx hash")
+	}
+
+	var op *types.BridgeOperation
+	err := s.db.View(func(tx kvTx) error {
+		opID := tx.Get(index, strings.ToLower(txHash))
+		if opID == nil {
+			return nil
+		}
+		var err error
+		op, err = getOpOrArchived(tx, string(opID))
+		return err
+	})
+	return op, err
+}
+
+func (s *kvStore) FindBridgeOperationByID(opID string) (*types.BridgeOperation, error) {
+	if opID == "" {
+		return nil, errors.New("empty bridge operation ID")
+	}
+
+	var op *types.BridgeOperation
+	err := s.db.View(func(tx kvTx) error {
+		var err error
+		op, err = getOpOrArchived(tx, opID)
+		return err
+	})
+	return op, err
+}
+
+func getOpOrArchived(tx kvTx, id string) (*types.BridgeOperation, error) {
+	op, err := getOp(tx, id)
+	if op != nil || err != nil {
+		return op, err
+	}
+
+	partition := tx.Get(bucketArchiveIdx, id)
+	if partition == nil {
+		return nil, nil
+	}
+	value := tx.Get(string(partition), id)
+	if value == nil {
+		return nil, nil
+	}
+	var archived types.BridgeOperation
+	err = json.Unmarshal(value, &archived)
+	if err != nil {
+		return nil, err
+	}
+	return &archived, nil
+}
+
+// moves finished operation from the hot status set into its monthly archive,
+// it stays reachable by ID and tx hash, but is not listed by status anymore
+func (s *kvStore) ArchiveBridgeOperation(op *types.BridgeOperation) error {
+	if op == nil || op.ID == "" {
+		return errors.New("bridge operation without ID cannot be archived")
+	}
+
+	opJSON, err := json.Marshal(op)
+	if err != nil {
+		return fmt.Errorf("cannot marshal bridge operation to JSON: %s", err.Error())
+	}
+
+	partition := archiveBucket(op.ArchiveMonth())
+	return s.db.Update(func(tx kvTx) error {
+		if tx.Get(statusBucket(op.Status), op.ID) == nil {
+			return fmt.Errorf("%w: operation %s is not %s", types.ErrStatusConflict, op.ID, op.Status)
+		}
+		if err := tx.Delete(statusBucket(op.Status), op.ID); err != nil {
+			return err
+		}
+		if err := tx.Delete(bucketOps, op.ID); err != nil {
+			return err
+		}
+		if err := tx.Put(partition, op.ID, opJSON); err != nil {
+			return err
+		}
+		return tx.Put(bucketArchiveIdx, op.ID, []byte(partition))
+	})
+}
+
+// all operations where address is either source or destination, archived included
+func (s *kvStore) FindBridgeOperationsByAddress(address string) ([]*types.BridgeOperation, error) {
+	if address == "" {
+		return nil, errors.New("empty search address")
+	}
+	return s.findOpsByIndexBucket(addressBucket(address))
+}
+
+// all operations created from an address book record (binding), archived included
+func (s *kvStore) FindBridgeOperationsByBinding(bindingID string) ([]*types.BridgeOperation, error) {
+	if bindingID == "" {
+		return nil, errors.New("empty search binding ID")
+	}
+	return s.findOpsByIndexBucket(bindingBucket(bindingID))
+}
+
+func (s *kvStore) findOpsByIndexBucket(index string) ([]*types.BridgeOperation, error) {
+	ops := make([]*types.BridgeOperation, 0)
+	err := s.db.View(func(tx kvTx) error {
+		return tx.ForEach(index, func(id string, _ []byte) error {
+			op, err := getOpOrArchived(tx, id)
+			if err != nil {
+				return err
+			}
+			if op != nil {
+				ops = append(ops, op)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// version of indexes built for records created before indexing existed
+const indexVersion = 2
+
+// builds lookup indexes for operations stored before indexing was introduced
+// (or before the current index version), does nothing if already done
+func (s *kvStore) EnsureIndexes() error {
+	indexed := 0
+	err := s.db.Update(func(tx kvTx) error {
+		version, _ := strconv.Atoi(string(tx.Get(bucketCheckpoints, "indexVersion")))
+		if version >= indexVersion {
+			return nil
+		}
+		err := tx.ForEach(bucketOps, func(_ string, value []byte) error {
+			var op types.BridgeOperation
+			if err := json.Unmarshal(value, &op); err != nil {
+				return err
+			}
+			indexed++
+			return putIndexes(tx, &op)
+		})
+		if err != nil {
+			return err
+		}
+		err = tx.ForEach(bucketArchiveIdx, func(id string, _ []byte) error {
+			op, err := getOpOrArchived(tx, id)
+			if err != nil || op == nil {
+				return err
+			}
+			indexed++
+			return putIndexes(tx, op)
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Put(bucketCheckpoints, "indexVersion", []byte(strconv.Itoa(indexVersion)))
+	})
+	if err == nil && indexed > 0 {
+		log.Printf("Built lookup indexes for %d bridge operations", indexed)
+	}
+	return err
+}
+
+func (s *kvStore) FindBridgeOperationStatus(status string) (*types.BridgeOperation, error) {
+	var found *types.BridgeOperation
+	err := s.db.View(func(tx kvTx) error {
+		return tx.ForEach(statusBucket(status), func(id string, _ []byte) error {
+			op, err := getOp(tx, id)
+			if err != nil {
+				return err
+			}
+			if op != nil && op.Status == status {
+				found = op
+				return errStop
+			}
+			return nil
+		})
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return nil, err
+	}
+	return found, nil
+}
+
+func (s *kvStore) FindAllBridgeOperationsByStatus(status string) ([]*types.BridgeOperation, error) {
+	if _, ok := config.RedisStatusSets[status]; !ok {
+		return nil, errors.New("storage key not found for status")
+	}
+
+	ops := make([]*types.BridgeOperation, 0)
+	err := s.db.View(func(tx kvTx) error {
+		return tx.ForEach(statusBucket(status), func(id string, _ []byte) error {
+			op, err := getOp(tx, id)
+			if err != nil {
+				return err
+			}
+			if op != nil && op.Status == status {
+				ops = append(ops, op)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+func (s *kvStore) CountBridgeOperationsByStatus(status string) (int, error) {
+	if _, ok := config.RedisStatusSets[status]; !ok {
+		return 0, errors.New("storage key not found for status")
+	}
+
+	count := 0
+	err := s.db.View(func(tx kvTx) error {
+		return tx.ForEach(statusBucket(status), func(string, []byte) error {
+			count++
+			return nil
+		})
+	})
+	return count, err
+}
+
+func addrBookKey(chainType types.ChainType, address string) string {
+	return fmt.Sprintf("%d:%s", chainType, strings.ToLower(address))
+}
+
+func (s *kvStore) UpsertAddressBookRecord(rec *types.AddressBookRecord) error {
+	if rec == nil {
+		return errors.New("null object to store")
+	}
+
+	if rec.SourceAddress == "" {
+		return errors.New("address book record cannot have empty source address")
+	}
+
+	// addrbook for all EVM->BGL mainnet tuples is the same
+	chainKeyPart := types.CHAINKEY_BGL
+	if rec.SourceChain > 0 {
+		chainKeyPart = types.CHAINKEY_EVM
+	}
+
+	if rec.ID == "" {
+		rec.ID = uuid.New().String()
+	}
+
+	recJSON, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("cannot marshal address book record to JSON: %s", err.Error())
+	}
+
+	return s.db.Update(func(tx kvTx) error {
+		return tx.Put(bucketAddrBook, addrBookKey(chainKeyPart, rec.SourceAddress), recJSON)
+	})
+}
+
+func (s *kvStore) FindAllAddressBookRecords() ([]*types.AddressBookRecord, error) {
+	recs := make([]*types.AddressBookRecord, 0)
+	err := s.db.View(func(tx kvTx) error {
+		return tx.ForEach(bucketAddrBook, func(_ string, value []byte) error {
+			var rec types.AddressBookRecord
+			if err := json.Unmarshal(value, &rec); err != nil {
+				return err
+			}
+			recs = append(recs, &rec)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recs, nil
+}
+
+func (s *kvStore) GetAddressBookBySourceAddress(chainType types.ChainType, address string) (*types.AddressBookRecord, error) {
+	var value []byte
+	err := s.db.View(func(tx kvTx) error {
+		value = tx.Get(bucketAddrBook, addrBookKey(chainType, address))
+		return nil
+	})
+	if err != nil || value == nil {
+		return nil, err
+	}
+
+	var addrbookRecord types.AddressBookRecord
+	err = json.Unmarshal(value, &addrbookRecord)
+	if err != nil {
+		return nil, err
+	}
+	return &addrbookRecord, nil
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"sort"
+	"sync"
+)
+
+// in-memory backend, data is lost on exit (tests, dry runs)
+type memoryBackend struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string][]byte
+}
+
+type memoryTx struct {
+	b        *memoryBackend
+	writable bool
+	// previous values to restore on failed update, nil means absent
+	undo []memoryUndo
+}
+
+type memoryUndo struct {
+	bucket, key string
+	value       []byte
+}
+
+func NewMemory() Store {
+	return &kvStore{db: &memoryBackend{buckets: map[string]map[string][]byte{}}}
+}
+
+func (b *memoryBackend) View(fn func(tx kvTx) error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return fn(&memoryTx{b: b})
+}
+
+func (b *memoryBackend) Update(fn func(tx kvTx) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tx := &memoryTx{b: b, writable: true}
+	err := fn(tx)
+	if err != nil {
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			u := tx.undo[i]
+			if u.value == nil {
+				delete(b.buckets[u.bucket], u.key)
+			} else {
+				b.buckets[u.bucket][u.key] = u.value
+			}
+		}
+	}
+	return err
+}
+
+func (b *memoryBackend) Close() error {
+	return nil
+}
+
+func (tx *memoryTx) Get(bucket, key string) []byte {
+	value, ok := tx.b.buckets[bucket][key]
+	if !ok {
+		return nil
+	}
+	return append([]byte{}, value...)
+}
+
+func (tx *memoryTx) set(bucket, key string, value []byte) {
+	if tx.b.buckets[bucket] == nil {
+		tx.b.buckets[bucket] = map[string][]byte{}
+	}
+	prev := tx.b.buckets[bucket][key]
+	tx.undo = append(tx.undo, memoryUndo{bucket: bucket, key: key, value: prev})
+
+	if value == nil {
+		delete(tx.b.buckets[bucket], key)
+	} else {
+		tx.b.buckets[bucket][key] = value
+	}
+}
+
+func (tx *memoryTx) Put(bucket, key string, value []byte) error {
+	if !tx.writable {
+		return errReadOnly
+	}
+	tx.set(bucket, key, append([]byte{}, value...))
+	return nil
+}
+
+func (tx *memoryTx) Delete(bucket, key string) error {
+	if !tx.writable {
+		return errReadOnly
+	}
+	tx.set(bucket, key, nil)
+	return nil
+}
+
+func (tx *memoryTx) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	keys := make([]string, 0, len(tx.b.buckets[bucket]))
+	for key := range tx.b.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		// bucket may be modified by fn
+		value, ok := tx.b.buckets[bucket][key]
+		if !ok {
+			continue
+		}
+		if err := fn(key, append([]byte{}, value...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"gobglbridge/types"
+)
+
+func TestChangeBridgeOperationStatus(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			op := &types.BridgeOperation{Status: "pending", SourceTxHash: "0xa"}
+			if err := s.UpsertBridgeOperation(op); err != nil {
+				t.Fatal(err)
+			}
+
+			op.Status = "executing"
+			if err := s.ChangeBridgeOperationStatus(op, "pending"); err != nil {
+				t.Fatalf("pending -> executing: %v", err)
+			}
+
+			// stale previous status is refused and nothing changes
+			stale := *op
+			stale.Status = "failed"
+			stale.Message = "stale"
+			err := s.ChangeBridgeOperationStatus(&stale, "pending")
+			if !errors.Is(err, types.ErrStatusConflict) {
+				t.Fatalf("stale transition error = %v, want ErrStatusConflict", err)
+			}
+			found, _ := s.FindBridgeOperationByID(op.ID)
+			if found.Status != "executing" || found.Message != "" {
+				t.Errorf("op after refused transition = %s %q", found.Status, found.Message)
+			}
+			if count, _ := s.CountBridgeOperationsByStatus("failed"); count != 0 {
+				t.Errorf("refused transition left op in failed set")
+			}
+
+			// op is listed in its new status only
+			for status, want := range map[string]int{"pending": 0, "executing": 1} {
+				ops, _ := s.FindAllBridgeOperationsByStatus(status)
+				if len(ops) != want {
+					t.Errorf("%s ops = %d, want %d", status, len(ops), want)
+				}
+			}
+
+			op.Status = "success"
+			if err := s.ChangeBridgeOperationStatus(op, "executing"); err != nil {
+				t.Fatalf("executing -> success: %v", err)
+			}
+		})
+	}
+}
+
+func TestChangeBridgeOperationStatusConcurrent(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			op := &types.BridgeOperation{Status: "pending", SourceTxHash: "0xb"}
+			if err := s.UpsertBridgeOperation(op); err != nil {
+				t.Fatal(err)
+			}
+
+			// executors racing for the same op, exactly one claims it
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			claimed, conflicts := 0, 0
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					claim := *op
+					claim.Status = "executing"
+					err := s.ChangeBridgeOperationStatus(&claim, "pending")
+					mu.Lock()
+					defer mu.Unlock()
+					if err == nil {
+						claimed++
+					} else if errors.Is(err, types.ErrStatusConflict) {
+						conflicts++
+					} else {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if claimed != 1 || conflicts != 9 {
+				t.Errorf("claimed %d times, %d conflicts", claimed, conflicts)
+			}
+		})
+	}
+}
+
+func TestArchiveBridgeOperation(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			op := &types.BridgeOperation{Status: "success", SourceTxHash: "0xc", DestTxHash: "0xd", SourceAddress: "0xsender"}
+			if err := s.UpsertBridgeOperation(op); err != nil {
+				t.Fatal(err)
+			}
+
+			// stale status is refused
+			stale := *op
+			stale.Status = "executing"
+			if err := s.ArchiveBridgeOperation(&stale); !errors.Is(err, types.ErrStatusConflict) {
+				t.Fatalf("archiving stale op error = %v, want ErrStatusConflict", err)
+			}
+
+			if err := s.ArchiveBridgeOperation(op); err != nil {
+				t.Fatalf("archiving op: %v", err)
+			}
+			if err := s.ArchiveBridgeOperation(op); !errors.Is(err, types.ErrStatusConflict) {
+				t.Errorf("archiving op twice error = %v, want ErrStatusConflict", err)
+			}
+
+			// no longer listed by status, still found by ID, tx hash and address
+			if count, _ := s.CountBridgeOperationsByStatus("success"); count != 0 {
+				t.Errorf("archived op still counted in success")
+			}
+			if found, err := s.FindBridgeOperationByID(op.ID); err != nil || found == nil || found.Status != "success" {
+				t.Errorf("FindBridgeOperationByID() of archived op = %v, %v", found, err)
+			}
+			if found, err := s.FindBridgeOperationDestinationTxHash("0xd"); err != nil || found == nil || found.ID != op.ID {
+				t.Errorf("FindBridgeOperationDestinationTxHash() of archived op = %v, %v", found, err)
+			}
+			if ops, err := s.FindBridgeOperationsByAddress("0xsender"); err != nil || len(ops) != 1 {
+				t.Errorf("FindBridgeOperationsByAddress() of archived op = %d ops, %v", len(ops), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"fmt"
+	"log"
+
+	"gobglbridge/config"
+	"gobglbridge/redis"
+	"gobglbridge/types"
+)
+
+// Store is the persistence engine used by workers and API handlers:
+// bridge operations, address book and scanners checkpoints
+type Store interface {
+	GetBGLScannedBlock() (string, error)
+	SetBGLScannedBlock(blockHash string) error
+	GetEVMScannedBlock(chainID int) (int, error)
+	SetEVMScannedBlock(chainID int, blockHeight int) error
+	// scanners move checkpoints on only if they are still old ("" or -1 if not set), atomically,
+	// so a checkpoint reset by operator meanwhile is kept
+	SwapBGLScannedBlock(old, new string) (bool, error)
+	SwapEVMScannedBlock(chainID int, old, new int) (bool, error)
+	// next custodian account nonce per EVM chain, -1 if not set
+	GetEVMNonce(chainID int) (int64, error)
+	// returns next nonce and increments it atomically, fails if not set
+	ReserveEVMNonce(chainID int) (int64, error)
+	// sets next nonce only if it is still old (-1 if not set), atomically
+	SwapEVMNonce(chainID int, old, new int64) (bool, error)
+
+	UpsertBridgeOperation(op *types.BridgeOperation) error
+	// atomic, fails with types.ErrStatusConflict if op is not in prevStatus anymore
+	ChangeBridgeOperationStatus(op *types.BridgeOperation, prevStatus string) error
+	FindBridgeOperationSourceTxHash(txHash string) (*types.BridgeOperation, error)
+	FindBridgeOperationDestinationTxHash(txHash string) (*types.BridgeOperation, error)
+	FindBridgeOperationByID(opID string) (*types.BridgeOperation, error)
+	FindBridgeOperationsByAddress(address string) ([]*types.BridgeOperation, error)
+	FindBridgeOperationsByBinding(bindingID string) ([]*types.BridgeOperation, error)
+	FindBridgeOperationStatus(status string) (*types.BridgeOperation, error)
+	FindAllBridgeOperationsByStatus(status string) ([]*types.BridgeOperation, error)
+	CountBridgeOperationsByStatus(status string) (int, error)
+	// moves finished op out of status sets, it stays reachable by ID and tx hash
+	ArchiveBridgeOperation(op *types.BridgeOperation) error
+
+	// sub-satoshi dust ledger per EVM chain, account -> wei; delta may be negative
+	AddDust(chainID int, account string, wei int64) error
+	GetDustLedger(chainID int) (map[string]int64, error)
+
+	// payouts of a route (e.g. "bgl_to_wbgl:1") for volume caps, one entry per op
+	AddOutflow(route string, opID string, ts int64, wei string) error
+	// sum of route payouts made since ts
+	GetOutflow(route string, since int64) (types.Amount, error)
+	// drops route payouts made before ts
+	PruneOutflow(route string, before int64) error
+
+	// operator actions, newest first
+	AppendAudit(entry *types.AuditEntry) error
+	GetAuditLog(limit int) ([]*types.AuditEntry, error)
+
+	UpsertAddressBookRecord(rec *types.AddressBookRecord) error
+	GetAddressBookBySourceAddress(chainType types.ChainType, address string) (*types.AddressBookRecord, error)
+	FindAllAddressBookRecords() ([]*types.AddressBookRecord, error)
+
+	// one-time migration of data stored before lookup indexes existed
+	EnsureIndexes() error
+
+	Close() error
+}
+
+var _ Store = (*redis.Store)(nil)
+var _ Store = (*kvStore)(nil)
+
+// DB is the store selected by configuration, set by Init
+var DB Store
+
+func Open(backend string) (Store, error) {
+	switch backend {
+	case "", "redis":
+		return redis.New(config.Config.Server.RedisHost, config.Config.Server.RedisPort), nil
+	case "bolt":
+		return NewBolt(config.Config.Server.StoragePath)
+	case "memory":
+		return NewMemory(), nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", backend)
+}
+
+// without persistence the bridge cannot operate, so error is fatal
+func Init() {
+	var err error
+	DB, err = Open(config.Config.Server.Storage)
+	if err != nil {
+		log.Fatalf("error opening storage: %s", err.Error())
+	}
+
+	err = DB.EnsureIndexes()
+	if err != nil {
+		log.Fatalf("error building storage indexes: %s", err.Error())
+	}
+}
@@ -0,0 +1,274 @@
+package store
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"gobglbridge/redis"
+	"gobglbridge/types"
+
+	redigo "github.com/gomodule/redigo/redis"
+)
+
+// embedded backends, plus redis when REDIS_TEST_ADDR (host:port) is set
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	bolt, err := NewBolt(filepath.Join(t.TempDir(), "bridge.db"))
+	if err != nil {
+		t.Fatalf("opening bolt: %v", err)
+	}
+	t.Cleanup(func() { bolt.Close() })
+	stores := map[string]Store{
+		"memory": NewMemory(),
+		"bolt":   bolt,
+	}
+	if s := testRedis(t); s != nil {
+		stores["redis"] = s
+	}
+	return stores
+}
+
+// redis store on an emptied database, nil if no server is reachable;
+// the database is flushed, so point REDIS_TEST_ADDR at a disposable server
+func testRedis(t *testing.T) Store {
+	t.Helper()
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Log("redis store not tested, REDIS_TEST_ADDR is not set")
+		return nil
+	}
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("invalid REDIS_TEST_ADDR %q: %v", addr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatalf("invalid REDIS_TEST_ADDR %q: %v", addr, err)
+	}
+
+	conn, err := redigo.Dial("tcp", addr)
+	if err != nil {
+		t.Logf("redis store not tested, %s is not reachable: %v", addr, err)
+		return nil
+	}
+	defer conn.Close()
+	if _, err := conn.Do("FLUSHDB"); err != nil {
+		t.Fatalf("flushing redis: %v", err)
+	}
+
+	s := redis.New(host, port)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestCheckpoints(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if hash, err := s.GetBGLScannedBlock(); err != nil || hash != "" {
+				t.Fatalf("GetBGLScannedBlock() = %q, %v, want empty", hash, err)
+			}
+			if height, err := s.GetEVMScannedBlock(1); err != nil || height != -1 {
+				t.Fatalf("GetEVMScannedBlock(1) = %d, %v, want -1", height, err)
+			}
+
+			if err := s.SetBGLScannedBlock("00abc"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetEVMScannedBlock(1, 100); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetEVMScannedBlock(56, 200); err != nil {
+				t.Fatal(err)
+			}
+
+			if hash, _ := s.GetBGLScannedBlock(); hash != "00abc" {
+				t.Errorf("GetBGLScannedBlock() = %q", hash)
+			}
+			if height, _ := s.GetEVMScannedBlock(1); height != 100 {
+				t.Errorf("GetEVMScannedBlock(1) = %d", height)
+			}
+			if height, _ := s.GetEVMScannedBlock(56); height != 200 {
+				t.Errorf("GetEVMScannedBlock(56) = %d", height)
+			}
+
+			// scanner does not overwrite a checkpoint reset meanwhile
+			if swapped, err := s.SwapEVMScannedBlock(1, 90, 150); err != nil || swapped {
+				t.Errorf("SwapEVMScannedBlock(1, 90, 150) = %v, %v", swapped, err)
+			}
+			if swapped, err := s.SwapEVMScannedBlock(1, 100, 150); err != nil || !swapped {
+				t.Errorf("SwapEVMScannedBlock(1, 100, 150) = %v, %v", swapped, err)
+			}
+			if swapped, _ := s.SwapEVMScannedBlock(137, -1, 10); !swapped {
+				t.Errorf("SwapEVMScannedBlock() of unset checkpoint failed")
+			}
+			if swapped, _ := s.SwapBGLScannedBlock("00old", "00new"); swapped {
+				t.Errorf("SwapBGLScannedBlock() replaced another checkpoint")
+			}
+			if swapped, _ := s.SwapBGLScannedBlock("00abc", "00new"); !swapped {
+				t.Errorf("SwapBGLScannedBlock() failed")
+			}
+			if height, _ := s.GetEVMScannedBlock(1); height != 150 {
+				t.Errorf("GetEVMScannedBlock(1) after swap = %d", height)
+			}
+			if hash, _ := s.GetBGLScannedBlock(); hash != "00new" {
+				t.Errorf("GetBGLScannedBlock() after swap = %q", hash)
+			}
+		})
+	}
+}
+
+func TestBridgeOperations(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			op := &types.BridgeOperation{
+				Status:        "pending",
+				SourceChain:   1,
+				Amount:        "1000000000000000000",
+				SourceAddress: "0xSender",
+				DestAddress:   "bgl1receiver",
+				SourceTxHash:  "0xABCDEF",
+				BindingID:     "binding-1",
+			}
+			if err := s.UpsertBridgeOperation(op); err != nil {
+				t.Fatal(err)
+			}
+			if op.ID == "" || op.TsUpdated == 0 {
+				t.Fatalf("stored op has ID %q, TsUpdated %d", op.ID, op.TsUpdated)
+			}
+
+			// tx hashes and addresses are case insensitive
+			found, err := s.FindBridgeOperationSourceTxHash("0xabcdef")
+			if err != nil || found == nil || found.ID != op.ID {
+				t.Fatalf("FindBridgeOperationSourceTxHash() = %v, %v", found, err)
+			}
+			found, err = s.FindBridgeOperationByID(op.ID)
+			if err != nil || found == nil || found.Amount != op.Amount {
+				t.Fatalf("FindBridgeOperationByID() = %v, %v", found, err)
+			}
+			if found, _ := s.FindBridgeOperationByID("missing"); found != nil {
+				t.Errorf("FindBridgeOperationByID(missing) = %v", found)
+			}
+			for _, address := range []string{"0xsender", "BGL1RECEIVER"} {
+				ops, err := s.FindBridgeOperationsByAddress(address)
+				if err != nil || len(ops) != 1 {
+					t.Errorf("FindBridgeOperationsByAddress(%s) = %d ops, %v", address, len(ops), err)
+				}
+			}
+			if ops, _ := s.FindBridgeOperationsByBinding("binding-1"); len(ops) != 1 {
+				t.Errorf("FindBridgeOperationsByBinding() = %d ops", len(ops))
+			}
+
+			op.DestTxHash = "0xDEST"
+			if err := s.UpsertBridgeOperation(op); err != nil {
+				t.Fatal(err)
+			}
+			found, err = s.FindBridgeOperationDestinationTxHash("0xdest")
+			if err != nil || found == nil || found.ID != op.ID {
+				t.Fatalf("FindBridgeOperationDestinationTxHash() = %v, %v", found, err)
+			}
+
+			if count, _ := s.CountBridgeOperationsByStatus("pending"); count != 1 {
+				t.Errorf("CountBridgeOperationsByStatus(pending) = %d", count)
+			}
+			if first, _ := s.FindBridgeOperationStatus("pending"); first == nil || first.ID != op.ID {
+				t.Errorf("FindBridgeOperationStatus(pending) = %v", first)
+			}
+			if first, _ := s.FindBridgeOperationStatus("failed"); first != nil {
+				t.Errorf("FindBridgeOperationStatus(failed) = %v", first)
+			}
+			if _, err := s.FindAllBridgeOperationsByStatus("unknown"); err == nil {
+				t.Errorf("FindAllBridgeOperationsByStatus(unknown) did not fail")
+			}
+		})
+	}
+}
+
+func TestInvalidBridgeOperation(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, op := range []*types.BridgeOperation{nil, {}, {Status: "unknown"}} {
+				if err := s.UpsertBridgeOperation(op); err == nil {
+					t.Errorf("UpsertBridgeOperation(%v) did not fail", op)
+				}
+			}
+			if err := s.ChangeBridgeOperationStatus(&types.BridgeOperation{Status: "failed"}, "pending"); err == nil {
+				t.Errorf("ChangeBridgeOperationStatus() without ID did not fail")
+			}
+			if count, _ := s.CountBridgeOperationsByStatus("unknown"); count != 0 {
+				t.Errorf("invalid op was stored")
+			}
+		})
+	}
+}
+
+func TestAddressBook(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			rec := &types.AddressBookRecord{
+				SourceChain:   0,
+				SourceAddress: "bgl1sender",
+				DestChain:     1,
+				DestAddress:   "0xReceiver",
+			}
+			if err := s.UpsertAddressBookRecord(rec); err != nil {
+				t.Fatal(err)
+			}
+			if rec.ID == "" {
+				t.Fatal("record ID not set")
+			}
+			if err := s.UpsertAddressBookRecord(&types.AddressBookRecord{}); err == nil {
+				t.Error("record without source address was stored")
+			}
+
+			found, err := s.GetAddressBookBySourceAddress(types.CHAINKEY_BGL, "BGL1SENDER")
+			if err != nil || found == nil || found.DestAddress != rec.DestAddress {
+				t.Fatalf("GetAddressBookBySourceAddress() = %v, %v", found, err)
+			}
+			// BGL and EVM sources are separate
+			if found, _ := s.GetAddressBookBySourceAddress(types.CHAINKEY_EVM, "bgl1sender"); found != nil {
+				t.Errorf("EVM lookup found BGL record %v", found)
+			}
+
+			// same source address replaces the record
+			rec.DestAddress = "0xOther"
+			if err := s.UpsertAddressBookRecord(rec); err != nil {
+				t.Fatal(err)
+			}
+			recs, err := s.FindAllAddressBookRecords()
+			if err != nil || len(recs) != 1 || recs[0].DestAddress != "0xOther" {
+				t.Fatalf("FindAllAddressBookRecords() = %v, %v", recs, err)
+			}
+		})
+	}
+}
+
+func TestBoltPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.db")
+	s, err := NewBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := &types.BridgeOperation{Status: "pending", SourceTxHash: "0xabc"}
+	if err := s.UpsertBridgeOperation(op); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetEVMScannedBlock(1, 42); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = NewBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if found, _ := s.FindBridgeOperationByID(op.ID); found == nil || found.SourceTxHash != "0xabc" {
+		t.Errorf("op after reopen = %v", found)
+	}
+	if height, _ := s.GetEVMScannedBlock(1); height != 42 {
+		t.Errorf("checkpoint after reopen = %d", height)
+	}
+}
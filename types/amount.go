@@ -0,0 +1,142 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// BGL has 8 decimals, WBGL 18: 1 satoshi is 10^10 wei
+const (
+	BGLDecimals  = 8
+	WBGLDecimals = 18
+)
+
+var (
+	weiPerSatoshi = big.NewInt(10_000_000_000)
+	satoshiPerBGL = big.NewInt(100_000_000)
+)
+
+// Amount is an exact BGL/WBGL amount kept in wei, it never goes through floats;
+// values are immutable, operations return new amounts
+type Amount struct {
+	wei *big.Int
+}
+
+func AmountFromWei(wei *big.Int) Amount {
+	return Amount{wei: new(big.Int).Set(wei)}
+}
+
+func AmountFromSatoshi(satoshi int64) Amount {
+	return Amount{wei: new(big.Int).Mul(big.NewInt(satoshi), weiPerSatoshi)}
+}
+
+// amount reported as float by BGL RPC, rounded to the nearest satoshi
+// (exact for any amount below 2^53 satoshi)
+func AmountFromBGLFloat(bgl float64) Amount {
+	return AmountFromSatoshi(int64(math.Round(bgl * 1e8)))
+}
+
+// parses integer wei string, e.g. WBGL transfer value
+func ParseWei(s string) (Amount, error) {
+	wei, ok := new(big.Int).SetString(s, 10)
+	if !ok || wei.Sign() < 0 {
+		return Amount{}, fmt.Errorf("invalid wei amount %q", s)
+	}
+	return Amount{wei: wei}, nil
+}
+
+// parses decimal BGL string with up to 8 decimals, e.g. "12.5" or "0.00000001"
+func ParseBGL(s string) (Amount, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || len(frac) > BGLDecimals || strings.ContainsAny(whole+frac, "+-") {
+		return Amount{}, fmt.Errorf("invalid BGL amount %q", s)
+	}
+	satoshi, ok := new(big.Int).SetString(whole+frac+strings.Repeat("0", BGLDecimals-len(frac)), 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("invalid BGL amount %q", s)
+	}
+	return Amount{wei: satoshi.Mul(satoshi, weiPerSatoshi)}, nil
+}
+
+func (a Amount) Wei() *big.Int {
+	if a.wei == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(a.wei)
+}
+
+// whole satoshis, sub-satoshi part is dropped
+func (a Amount) Satoshi() int64 {
+	return new(big.Int).Quo(a.Wei(), weiPerSatoshi).Int64()
+}
+
+// amount rounded down to whole satoshis, what can be sent on BGL
+func (a Amount) TruncateSatoshi() Amount {
+	return AmountFromSatoshi(a.Satoshi())
+}
+
+// sub-satoshi part lost when sending on BGL
+func (a Amount) Dust() Amount {
+	return Amount{wei: new(big.Int).Rem(a.Wei(), weiPerSatoshi)}
+}
+
+func (a Amount) Add(b Amount) Amount {
+	return Amount{wei: new(big.Int).Add(a.Wei(), b.Wei())}
+}
+
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{wei: new(big.Int).Sub(a.Wei(), b.Wei())}
+}
+
+// a * num / den rounded down, e.g. fee percentage or basis points
+func (a Amount) MulDiv(num, den int64) Amount {
+	wei := new(big.Int).Mul(a.Wei(), big.NewInt(num))
+	return Amount{wei: wei.Quo(wei, big.NewInt(den))}
+}
+
+func (a Amount) Cmp(b Amount) int {
+	return a.Wei().Cmp(b.Wei())
+}
+
+func (a Amount) Sign() int {
+	return a.Wei().Sign()
+}
+
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+// integer wei, the form stored for WBGL amounts
+func (a Amount) WeiString() string {
+	return a.Wei().String()
+}
+
+// exact BGL decimal with 8 places, e.g. "12.50000000", sub-satoshi part is dropped
+func (a Amount) BGLString() string {
+	satoshi := big.NewInt(a.Satoshi())
+	sign := ""
+	if satoshi.Sign() < 0 {
+		sign = "-"
+		satoshi.Neg(satoshi)
+	}
+	whole, frac := new(big.Int).QuoRem(satoshi, satoshiPerBGL, new(big.Int))
+	return fmt.Sprintf("%s%s.%08d", sign, whole, frac.Int64())
+}
+
+// exact WBGL decimal without trailing zeros, for logs and API
+func (a Amount) String() string {
+	wei := a.Wei()
+	sign := ""
+	if wei.Sign() < 0 {
+		sign = "-"
+		wei.Neg(wei)
+	}
+	s := fmt.Sprintf("%019s", wei.String())
+	whole, frac := s[:len(s)-WBGLDecimals], strings.TrimRight(s[len(s)-WBGLDecimals:], "0")
+	if frac == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + frac
+}
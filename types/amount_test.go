@@ -0,0 +1,203 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+func weiAmount(t *testing.T, s string) Amount {
+	t.Helper()
+	wei, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		t.Fatalf("bad test wei %q", s)
+	}
+	return AmountFromWei(wei)
+}
+
+func TestParseBGL(t *testing.T) {
+	tests := []struct {
+		in  string
+		wei string
+		ok  bool
+	}{
+		{"12.5", "12500000000000000000", true},
+		{"0.00000001", "10000000000", true},
+		{"21000000.12345678", "21000000123456780000000000", true},
+		{"1.", "1000000000000000000", true},
+		{"0", "0", true},
+		{"007", "7000000000000000000", true},
+		{"", "", false},
+		{".5", "", false},
+		{"1.123456789", "", false},
+		{"-1", "", false},
+		{"+1", "", false},
+		{"1.-5", "", false},
+		{"1e5", "", false},
+		{"1,5", "", false},
+		{"abc", "", false},
+	}
+	for _, tt := range tests {
+		a, err := ParseBGL(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseBGL(%q) error = %v, want ok %v", tt.in, err, tt.ok)
+			continue
+		}
+		if tt.ok && a.WeiString() != tt.wei {
+			t.Errorf("ParseBGL(%q) = %s wei, want %s", tt.in, a.WeiString(), tt.wei)
+		}
+	}
+}
+
+func TestParseWei(t *testing.T) {
+	tests := []struct {
+		in string
+		ok bool
+	}{
+		{"0", true},
+		{"1", true},
+		{"1234567890123456789", true},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", true},
+		{"", false},
+		{"-1", false},
+		{"1.5", false},
+		{"0x10", false},
+		{"1e18", false},
+	}
+	for _, tt := range tests {
+		a, err := ParseWei(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseWei(%q) error = %v, want ok %v", tt.in, err, tt.ok)
+			continue
+		}
+		if tt.ok && a.WeiString() != tt.in {
+			t.Errorf("ParseWei(%q) = %s", tt.in, a.WeiString())
+		}
+	}
+}
+
+func TestAmountFromBGLFloat(t *testing.T) {
+	tests := []struct {
+		in  float64
+		bgl string
+	}{
+		{0, "0.00000000"},
+		{0.1 + 0.2, "0.30000000"},
+		{12.5, "12.50000000"},
+		{0.00000001, "0.00000001"},
+		{0.000000004, "0.00000000"},
+		{0.000000006, "0.00000001"},
+		{1.99999999, "1.99999999"},
+		{21000000.12345678, "21000000.12345678"},
+		{-0.5, "-0.50000000"},
+	}
+	for _, tt := range tests {
+		if got := AmountFromBGLFloat(tt.in).BGLString(); got != tt.bgl {
+			t.Errorf("AmountFromBGLFloat(%v) = %s, want %s", tt.in, got, tt.bgl)
+		}
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	tests := []struct {
+		wei string
+		str string
+		bgl string
+	}{
+		{"0", "0", "0.00000000"},
+		{"5", "0.000000000000000005", "0.00000000"},
+		{"10000000000", "0.00000001", "0.00000001"},
+		{"1000000000000000000", "1", "1.00000000"},
+		{"12500000000000000000", "12.5", "12.50000000"},
+		{"1234567890123456789", "1.234567890123456789", "1.23456789"},
+		{"21000000123456789999999999", "21000000.123456789999999999", "21000000.12345678"},
+		{"-1500000000000000000", "-1.5", "-1.50000000"},
+		{"-5", "-0.000000000000000005", "0.00000000"},
+	}
+	for _, tt := range tests {
+		a := weiAmount(t, tt.wei)
+		if got := a.String(); got != tt.str {
+			t.Errorf("String() of %s wei = %s, want %s", tt.wei, got, tt.str)
+		}
+		if got := a.BGLString(); got != tt.bgl {
+			t.Errorf("BGLString() of %s wei = %s, want %s", tt.wei, got, tt.bgl)
+		}
+	}
+
+	var zero Amount
+	if zero.String() != "0" || zero.BGLString() != "0.00000000" || !zero.IsZero() {
+		t.Errorf("zero value = %s / %s", zero.String(), zero.BGLString())
+	}
+}
+
+func TestAmountMulDiv(t *testing.T) {
+	tests := []struct {
+		wei      string
+		num, den int64
+		want     string
+	}{
+		{"1000", 1, 100, "10"},
+		{"999", 1, 1000, "0"},
+		{"1999", 1, 1000, "1"},
+		{"1234567890123456789", 1, 100, "12345678901234567"},
+		{"1000000000000000000", 25, 10000, "2500000000000000"},
+		{"12500000000000000000", 3, 1000, "37500000000000000"},
+		{"21000000123456789999999999", 9999, 10000, "20997900123444444320999999"},
+		{"0", 50, 10000, "0"},
+		{"-1999", 1, 1000, "-1"},
+	}
+	for _, tt := range tests {
+		if got := weiAmount(t, tt.wei).MulDiv(tt.num, tt.den).WeiString(); got != tt.want {
+			t.Errorf("%s.MulDiv(%d, %d) = %s, want %s", tt.wei, tt.num, tt.den, got, tt.want)
+		}
+	}
+}
+
+func TestAmountDust(t *testing.T) {
+	tests := []struct {
+		wei      string
+		dust     string
+		truncate string
+	}{
+		{"0", "0", "0"},
+		{"9999999999", "9999999999", "0"},
+		{"10000000000", "0", "10000000000"},
+		{"1234567890123456789", "123456789", "1234567890000000000"},
+		{"21000000123456789999999999", "9999999999", "21000000123456780000000000"},
+		{"-15000000000", "-5000000000", "-10000000000"},
+	}
+	for _, tt := range tests {
+		a := weiAmount(t, tt.wei)
+		dust, truncated := a.Dust(), a.TruncateSatoshi()
+		if dust.WeiString() != tt.dust {
+			t.Errorf("Dust() of %s wei = %s, want %s", tt.wei, dust.WeiString(), tt.dust)
+		}
+		if truncated.WeiString() != tt.truncate {
+			t.Errorf("TruncateSatoshi() of %s wei = %s, want %s", tt.wei, truncated.WeiString(), tt.truncate)
+		}
+		// nothing is lost when splitting
+		if truncated.Add(dust).Cmp(a) != 0 {
+			t.Errorf("%s wei split into %s + %s", tt.wei, truncated.WeiString(), dust.WeiString())
+		}
+	}
+}
+
+func TestAmountArithmetic(t *testing.T) {
+	a := weiAmount(t, "1234567890123456789")
+	fee := a.MulDiv(1, 100)
+	if a.Sub(fee).Add(fee).Cmp(a) != 0 {
+		t.Errorf("%s - %s + %s != %s", a, fee, fee, a)
+	}
+	if fee.Sub(a).Sign() >= 0 || a.Sub(fee).Sign() <= 0 {
+		t.Errorf("Sign() of differences of %s and %s", a, fee)
+	}
+
+	// values are immutable
+	wei := big.NewInt(100)
+	b := AmountFromWei(wei)
+	wei.SetInt64(1)
+	b.Wei().SetInt64(2)
+	b.Add(AmountFromSatoshi(1))
+	if b.WeiString() != "100" {
+		t.Errorf("amount changed to %s", b.WeiString())
+	}
+}
@@ -1,5 +1,13 @@
 package types
 
+import (
+	"errors"
+	"time"
+)
+
+// returned by status change when operation is not in the expected previous status
+var ErrStatusConflict = errors.New("bridge operation status conflict")
+
 // it is assumed BGL mainnet is id 0
 // Eth mainnet id 1
 // BNB id 56, etc.
@@ -30,10 +38,61 @@ type BridgeOperation struct {
 	SourceChain   int
 	DestChain     int
 	TsFound       int64
+	TsUpdated     int64  // last time the record was stored
 	Amount        string // amount in WEI (1e18) or in BGL Satoshis (only have 1e8 precision)
 	SourceAddress string
-	DestAddress   string // filled when destination transaction is executed (or returned)
-	SourceTxHash  string // transaction where funds are received by bridge
-	DestTxHash    string // transaction where funds are sent by bridge
-	Message       string // messsages that help to track processing/errors
+	DestAddress   string  // filled when destination transaction is executed (or returned)
+	SourceTxHash  string  // transaction where funds are received by bridge
+	DestTxHash    string  // transaction where funds are sent by bridge
+	Message       string  // messsages that help to track processing/errors
+	BindingID     string  // address book record (binding) that routed the operation
+	DestTxNonce   *uint64 // nonce of EVM destination tx, reused when re-driving a never mined tx
+	Retries       int     // times operation was re-driven after failed destination tx
+	// earlier destination txs with the same nonce, replaced by DestTxHash with a higher fee,
+	// any of them can end up mined
+	ReplacedTxHashes []string
+	DestGasLimit     uint64 // estimated gas limit of EVM destination tx, with safety margin
+	DestTxFee        string // fee paid by mined EVM destination tx in wei (gas used times effective gas price), set once finalized
+	DestRawTx        string // signed EVM destination tx (hex), stored before broadcast and rebroadcast if lost
+	Dust             string // wei dropped converting to BGL (less than a satoshi)
+	DustRefund       string // wei of sender's earlier dust paid out with this operation
+	Approved         bool   // operator approved amount above maximum
+	ForceReturn      bool   // operator decided to return funds to sender instead of bridging them
+}
+
+// operator action on a bridge operation or binding, see admin package
+type AuditEntry struct {
+	Ts     int64
+	Actor  string // operator name given by client
+	Action string // e.g. "requeue", "binding"
+	Target string // operation or binding ID
+	Reason string
+	Before string // status (binding: destination) before the action
+	After  string
+}
+
+// amount received on source chain: BGL decimal string from BGL, wei from EVM chains
+func (op *BridgeOperation) SourceAmount() (Amount, error) {
+	if op.SourceChain == 0 {
+		return ParseBGL(op.Amount)
+	}
+	return ParseWei(op.Amount)
+}
+
+// adds a processing/error message to the ones recorded earlier
+func (op *BridgeOperation) AppendMessage(msg string) {
+	if op.Message == "" {
+		op.Message = msg
+	} else {
+		op.Message += "; " + msg
+	}
+}
+
+// month partition the operation is archived under once finished, e.g. "2024-07"
+func (op *BridgeOperation) ArchiveMonth() string {
+	ts := op.TsUpdated
+	if ts == 0 {
+		ts = op.TsFound
+	}
+	return time.Unix(ts, 0).UTC().Format("2006-01")
 }
@@ -0,0 +1,66 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gobglbridge/config"
+	"gobglbridge/store"
+	"gobglbridge/types"
+)
+
+// finished operations are only needed for history lookups, keep them out of status sets
+var archivedStatuses = []string{"success", "returnsuccess"}
+
+func Worker_archive(ctx context.Context) {
+	if config.Config.Server.ArchiveAfterDays <= 0 {
+		log.Printf("Archiving of finished bridge operations is disabled")
+		return
+	}
+
+	lastRun := time.Time{}
+	for sleep(ctx, 10*time.Second) {
+
+		// no need to run often, hourly is plenty
+		if time.Since(lastRun) < time.Hour {
+			continue
+		}
+		lastRun = time.Now()
+
+		cutoff := time.Now().AddDate(0, 0, -config.Config.Server.ArchiveAfterDays).Unix()
+		archived := 0
+
+		for _, status := range archivedStatuses {
+			ops, err := store.DB.FindAllBridgeOperationsByStatus(status)
+			if err != nil {
+				log.Printf("Error getting %s bridge operations to archive: %v", status, err)
+				continue
+			}
+
+			for _, op := range ops {
+				ts := op.TsUpdated
+				if ts == 0 {
+					ts = op.TsFound
+				}
+				if ts > cutoff {
+					continue
+				}
+
+				err = store.DB.ArchiveBridgeOperation(op)
+				if errors.Is(err, types.ErrStatusConflict) {
+					continue
+				} else if err != nil {
+					log.Printf("Error archiving bridge operation %s: %v", op.ID, err)
+					break
+				}
+				archived++
+			}
+		}
+
+		if archived > 0 {
+			log.Printf("Archived %d finished bridge operations", archived)
+		}
+	}
+}
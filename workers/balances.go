@@ -0,0 +1,42 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"time"
+
+	"gobglbridge/BGLRPC"
+	"gobglbridge/config"
+	"gobglbridge/metrics"
+	"gobglbridge/workers/handlers"
+)
+
+// custodian balances are refreshed in background, so metrics scrapes never hit RPCs
+func Worker_balances(ctx context.Context) {
+	lastRun := time.Time{}
+	for sleep(ctx, 5*time.Second) {
+
+		if time.Since(lastRun) < time.Minute {
+			continue
+		}
+		lastRun = time.Now()
+
+		balanceBGL, err := BGLRPC.GetClient().GetBalance()
+		if err != nil {
+			log.Printf("Error getting BGL custodian balance: %s", err.Error())
+		} else {
+			metrics.CustodianBalance.WithLabelValues("BGL", "BGL").Set(balanceBGL)
+		}
+
+		for chainId, chain := range config.EVMChains {
+			balanceWBGL, err := handlers.WBGLBalanceInt(chainId)
+			if err != nil {
+				continue
+			}
+			balanceBF := new(big.Float).Quo(new(big.Float).SetInt(balanceWBGL), big.NewFloat(1e18))
+			balanceFloat, _ := balanceBF.Float64()
+			metrics.CustodianBalance.WithLabelValues("WBGL", chain.Name).Set(balanceFloat)
+		}
+	}
+}
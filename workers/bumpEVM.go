@@ -0,0 +1,99 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"time"
+
+	"gobglbridge/config"
+	"gobglbridge/store"
+	"gobglbridge/types"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// replaces destination tx pending for too long by the same transfer with the same nonce
+// and higher fees, whichever of them gets mined finalizes the operation
+func bumpEVMTx(ctx context.Context, op *types.BridgeOperation, stuck *ethtypes.Transaction) {
+	chain := config.EVMChains[op.DestChain]
+	if time.Since(time.Unix(op.TsUpdated, 0)) < time.Duration(chain.Gas.BumpAfter)*time.Minute {
+		return
+	}
+	ceiling := chain.Gas.MaxPrice()
+	if ceiling == nil {
+		return
+	}
+	if op.DestTxNonce != nil && *op.DestTxNonce != stuck.Nonce() {
+		log.Printf("Bridge operation %s tx %s nonce %d differs from recorded %d, not replacing", op.ID, stuck.Hash().Hex(), stuck.Nonce(), *op.DestTxNonce)
+		return
+	}
+	if stuck.GasFeeCap().Cmp(ceiling) >= 0 {
+		log.Printf("Bridge operation %s tx %s is pending, fees already at %s cap", op.ID, stuck.Hash().Hex(), chain.Name)
+		return
+	}
+
+	// market may have moved more than the bump
+	fees, err := suggestFees(ctx, op.DestChain)
+	if err != nil {
+		log.Printf("Error getting suggested %s gas fees: %v", chain.Name, err)
+		return
+	}
+	feeCap := capFee(maxFee(percentOf(stuck.GasFeeCap(), 100+chain.Gas.BumpPercent), fees.feeCap), ceiling)
+
+	var txData ethtypes.TxData
+	if stuck.Type() == ethtypes.DynamicFeeTxType {
+		tipCap := maxFee(percentOf(stuck.GasTipCap(), 100+chain.Gas.BumpPercent), fees.tipCap)
+		txData = &ethtypes.DynamicFeeTx{
+			ChainID:   big.NewInt(int64(op.DestChain)),
+			Nonce:     stuck.Nonce(),
+			GasTipCap: capFee(tipCap, feeCap),
+			GasFeeCap: feeCap,
+			Gas:       stuck.Gas(),
+			To:        stuck.To(),
+			Value:     stuck.Value(),
+			Data:      stuck.Data(),
+		}
+	} else {
+		txData = &ethtypes.LegacyTx{
+			Nonce:    stuck.Nonce(),
+			To:       stuck.To(),
+			Value:    stuck.Value(),
+			Gas:      stuck.Gas(),
+			GasPrice: feeCap,
+			Data:     stuck.Data(),
+		}
+	}
+
+	privateKey, err := crypto.HexToECDSA(config.Config.EVM.PrivateKey)
+	if err != nil {
+		log.Printf("Error instantiating private key: %v", err)
+		return
+	}
+	replacement, err := ethtypes.SignNewTx(privateKey, ethtypes.LatestSignerForChainID(big.NewInt(int64(op.DestChain))), txData)
+	if err != nil {
+		log.Printf("Error signing replacement of tx %s: %v", stuck.Hash().Hex(), err)
+		return
+	}
+
+	// recorded before broadcast, so scanner recognizes the replacement whenever it is mined
+	nonce := stuck.Nonce()
+	op.DestTxNonce = &nonce
+	setDestEVMTx(op, replacement)
+	err = store.DB.ChangeBridgeOperationStatus(op, op.Status)
+	if err != nil {
+		log.Printf("Error saving replacement tx of bridge operation %s: %v", op.ID, err)
+		return
+	}
+
+	log.Printf(
+		"Bridge operation %s: replacing %s tx %s (nonce %d) by %s, max fee per gas %s -> %s",
+		op.ID, chain.Name, stuck.Hash().Hex(), nonce, op.DestTxHash, stuck.GasFeeCap(), feeCap,
+	)
+	err = broadcastEVMTx(ctx, op.DestChain, replacement)
+	if err != nil {
+		// original tx stays pending, next attempt starts from it again
+		log.Printf("Error broadcasting replacement tx %s: %v", op.DestTxHash, err)
+	}
+}
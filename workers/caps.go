@@ -0,0 +1,203 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gobglbridge/config"
+	"gobglbridge/fees"
+	"gobglbridge/metrics"
+	"gobglbridge/store"
+	"gobglbridge/types"
+)
+
+// payouts older than the longest cap window are dropped
+const outflowWindow = 24 * time.Hour
+
+func outflowRoute(direction string, chainId int) string {
+	return fmt.Sprintf("%s:%d", direction, chainId)
+}
+
+// payout direction and EVM chain of op
+func opRoute(op *types.BridgeOperation) (string, int) {
+	if op.SourceChain > 0 {
+		return config.DirectionWBGLToBGL, op.SourceChain
+	}
+	return config.DirectionBGLToWBGL, op.DestChain
+}
+
+// return pays out the other way, on the EVM chain of op
+func returnRoute(op *types.BridgeOperation) (string, int) {
+	if op.SourceChain > 0 {
+		return config.DirectionBGLToWBGL, op.SourceChain
+	}
+	chainId := op.DestChain
+	if chainId == 0 {
+		// BGL return was attempted, EVM chain comes from the binding
+		rec, err := store.DB.GetAddressBookBySourceAddress(types.CHAINKEY_BGL, op.SourceAddress)
+		if err != nil {
+			log.Printf("Error getting address book record of bridge operation %s: %v", op.ID, err)
+		} else if rec != nil {
+			chainId = rec.DestChain
+		}
+	}
+	return config.DirectionWBGLToBGL, chainId
+}
+
+// route op pays out on next: its return if operator or executor decided so
+func payoutRoute(op *types.BridgeOperation) (string, int) {
+	if op.ForceReturn {
+		return returnRoute(op)
+	}
+	return opRoute(op)
+}
+
+// checks that paying amount (on top of queued, not yet recorded) keeps route within its caps,
+// returns reason if it does not
+func checkCaps(direction string, chainId int, amount types.Amount, queued types.Amount) (string, error) {
+	caps := fees.Cap(direction, chainId)
+	windows := []struct {
+		name   string
+		period time.Duration
+		set    bool
+		cap    types.Amount
+	}{
+		{"hourly", time.Hour, caps.Rule.Hourly != "", caps.Hourly},
+		{"daily", outflowWindow, caps.Rule.Daily != "", caps.Daily},
+	}
+
+	for _, window := range windows {
+		if !window.set {
+			continue
+		}
+		sent, err := store.DB.GetOutflow(outflowRoute(direction, chainId), time.Now().Add(-window.period).Unix())
+		if err != nil {
+			return "", err
+		}
+		if sent.Add(queued).Add(amount).Cmp(window.cap) > 0 {
+			return fmt.Sprintf("%s cap %s reached (sent %s)", window.name, window.cap, sent), nil
+		}
+	}
+	return "", nil
+}
+
+// reason payout of amount by op on route has to wait: older ops of the route are paused or amount exceeds route caps
+func payoutBlocked(op *types.BridgeOperation, direction string, chainId int, amount types.Amount) (string, error) {
+	paused, err := store.DB.FindAllBridgeOperationsByStatus("paused")
+	if err != nil {
+		return "", err
+	}
+	for _, other := range paused {
+		if other.TsFound >= op.TsFound {
+			continue
+		}
+		otherDirection, otherChain := payoutRoute(other)
+		if otherDirection == direction && otherChain == chainId {
+			return "route is paused", nil
+		}
+	}
+	return checkCaps(direction, chainId, amount, types.Amount{})
+}
+
+// payout is already made, so failing to record it is only logged
+func recordOutflow(op *types.BridgeOperation, direction string, chainId int, amount types.Amount) {
+	err := store.DB.AddOutflow(outflowRoute(direction, chainId), op.ID, time.Now().Unix(), amount.WeiString())
+	if err != nil {
+		log.Printf("Error recording outflow of bridge operation %s: %v", op.ID, err)
+	}
+}
+
+// circuit breaker: route stays paused while it has paused ops
+func pauseRoute(direction string, chainId int, reason string) {
+	log.Printf("ALERT: %s payouts on %s paused: %s", direction, config.EVMChains[chainId].Name, reason)
+	metrics.RoutePaused.WithLabelValues(direction, metrics.ChainName(chainId)).Set(1)
+	metrics.CapTrips.WithLabelValues(direction, metrics.ChainName(chainId)).Inc()
+}
+
+// amount op would pay out next, executor checks caps with the same
+func payoutAmount(op *types.BridgeOperation) (types.Amount, error) {
+	amount, err := op.SourceAmount()
+	if err != nil || op.ForceReturn {
+		return amount, err
+	}
+	direction, chainId := opRoute(op)
+	quote := fees.Compute(direction, chainId, amount)
+	if direction == config.DirectionWBGLToBGL {
+		// BGL payout is whole satoshis plus refunded dust
+		send, _, _, err := splitDust(op, quote.Receive)
+		return send, err
+	}
+	return quote.Receive, nil
+}
+
+// resumes paused ops, oldest first, once route caps have room for them
+func Worker_caps(ctx context.Context) {
+	for sleep(ctx, time.Minute) {
+		for _, chainId := range config.ChainIDs() {
+			for _, direction := range []string{config.DirectionBGLToWBGL, config.DirectionWBGLToBGL} {
+				err := store.DB.PruneOutflow(outflowRoute(direction, chainId), time.Now().Add(-outflowWindow).Unix())
+				if err != nil {
+					log.Printf("Error pruning %s outflow: %v", outflowRoute(direction, chainId), err)
+				}
+			}
+		}
+
+		paused, err := store.DB.FindAllBridgeOperationsByStatus("paused")
+		if err != nil {
+			log.Printf("Error getting paused bridge operations: %v", err)
+			continue
+		}
+		sort.Slice(paused, func(i, j int) bool { return paused[i].TsFound < paused[j].TsFound })
+
+		queued := map[string]types.Amount{}
+		blocked := map[string]bool{}
+		for _, op := range paused {
+			direction, chainId := payoutRoute(op)
+			route := outflowRoute(direction, chainId)
+			if blocked[route] {
+				continue
+			}
+
+			amount, err := payoutAmount(op)
+			if err != nil {
+				// executor fails it
+				amount = types.Amount{}
+			}
+			reason, err := checkCaps(direction, chainId, amount, queued[route])
+			if err != nil {
+				log.Printf("Error checking %s caps: %v", route, err)
+				blocked[route] = true
+				continue
+			}
+			if reason != "" {
+				// keep order, younger ops wait for older ones
+				blocked[route] = true
+				continue
+			}
+
+			op.Status = "pending"
+			err = store.DB.ChangeBridgeOperationStatus(op, "paused")
+			if errors.Is(err, types.ErrStatusConflict) {
+				continue
+			} else if err != nil {
+				log.Printf("Error resuming bridge operation %s: %v", op.ID, err)
+				blocked[route] = true
+				continue
+			}
+			log.Printf("Bridge operation %s resumed, %s caps have room", op.ID, route)
+			queued[route] = queued[route].Add(amount)
+		}
+
+		for _, chainId := range config.ChainIDs() {
+			for _, direction := range []string{config.DirectionBGLToWBGL, config.DirectionWBGLToBGL} {
+				if !blocked[outflowRoute(direction, chainId)] {
+					metrics.RoutePaused.WithLabelValues(direction, metrics.ChainName(chainId)).Set(0)
+				}
+			}
+		}
+	}
+}
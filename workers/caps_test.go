@@ -0,0 +1,160 @@
+package workers
+
+import (
+	"testing"
+	"time"
+
+	"gobglbridge/config"
+	"gobglbridge/store"
+	"gobglbridge/types"
+)
+
+// memory store and chain 1 (eth) with caps, restored after test
+func setCaps(t *testing.T, caps []config.CapRule) {
+	t.Helper()
+	db, chains, cfg := store.DB, config.EVMChains, config.Config
+	t.Cleanup(func() { store.DB, config.EVMChains, config.Config = db, chains, cfg })
+	store.DB = store.NewMemory()
+	config.EVMChains = map[int]config.ChainConfig{
+		1: {Name: "Ethereum", ShortCode: "eth", ChainID: 1},
+	}
+	config.Config.Fees = nil
+	config.Config.Caps = caps
+}
+
+func bgl(t *testing.T, s string) types.Amount {
+	t.Helper()
+	amount, err := types.ParseBGL(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return amount
+}
+
+func TestCheckCaps(t *testing.T) {
+	setCaps(t, []config.CapRule{
+		{Direction: config.DirectionBGLToWBGL, Hourly: "100", Daily: "150"},
+	})
+	route := outflowRoute(config.DirectionBGLToWBGL, 1)
+	now := time.Now()
+	store.DB.AddOutflow(route, "hour", now.Add(-10*time.Minute).Unix(), bgl(t, "60").WeiString())
+	store.DB.AddOutflow(route, "day", now.Add(-5*time.Hour).Unix(), bgl(t, "50").WeiString())
+	store.DB.AddOutflow(route, "old", now.Add(-25*time.Hour).Unix(), bgl(t, "1000").WeiString())
+
+	tests := []struct {
+		direction string
+		amount    string
+		queued    string
+		blocked   bool
+	}{
+		{config.DirectionBGLToWBGL, "40", "0", false},
+		// hourly: 60 + 40.00000001
+		{config.DirectionBGLToWBGL, "40.00000001", "0", true},
+		{config.DirectionBGLToWBGL, "20", "20", false},
+		{config.DirectionBGLToWBGL, "20", "20.00000001", true},
+		// other direction has no caps
+		{config.DirectionWBGLToBGL, "1000000", "0", false},
+	}
+	for _, tt := range tests {
+		reason, err := checkCaps(tt.direction, 1, bgl(t, tt.amount), bgl(t, tt.queued))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (reason != "") != tt.blocked {
+			t.Errorf("checkCaps(%s, %s, queued %s) = %q, want blocked %v", tt.direction, tt.amount, tt.queued, reason, tt.blocked)
+		}
+	}
+
+	// daily: 110 sent within a day
+	config.Config.Caps[0].Hourly = ""
+	if reason, _ := checkCaps(config.DirectionBGLToWBGL, 1, bgl(t, "40.00000001"), types.Amount{}); reason == "" {
+		t.Errorf("daily cap not applied")
+	}
+}
+
+func TestPayoutBlocked(t *testing.T) {
+	setCaps(t, nil)
+
+	// WBGL to BGL op paused on eth blocks younger ones of the route only
+	paused := &types.BridgeOperation{Status: "paused", SourceChain: 1, Amount: "1", TsFound: 100}
+	if err := store.DB.UpsertBridgeOperation(paused); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		op        *types.BridgeOperation
+		direction string
+		blocked   bool
+	}{
+		{"younger", &types.BridgeOperation{SourceChain: 1, TsFound: 200}, config.DirectionWBGLToBGL, true},
+		{"older", &types.BridgeOperation{SourceChain: 1, TsFound: 50}, config.DirectionWBGLToBGL, false},
+		{"other route", &types.BridgeOperation{DestChain: 1, TsFound: 200}, config.DirectionBGLToWBGL, false},
+		// BGL return of BGL to WBGL op pays out BGL like the paused op
+		{"return", &types.BridgeOperation{DestChain: 1, TsFound: 200, ForceReturn: true}, config.DirectionWBGLToBGL, true},
+	}
+	for _, tt := range tests {
+		direction, chainId := payoutRoute(tt.op)
+		if direction != tt.direction || chainId != 1 {
+			t.Errorf("%s: payoutRoute() = %s:%d", tt.name, direction, chainId)
+			continue
+		}
+		reason, err := payoutBlocked(tt.op, direction, chainId, types.Amount{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (reason != "") != tt.blocked {
+			t.Errorf("%s: payoutBlocked() = %q, want blocked %v", tt.name, reason, tt.blocked)
+		}
+	}
+
+	// paused return blocks the route it pays out on
+	paused.ForceReturn = true
+	if err := store.DB.UpsertBridgeOperation(paused); err != nil {
+		t.Fatal(err)
+	}
+	op := &types.BridgeOperation{DestChain: 1, TsFound: 200}
+	if reason, _ := payoutBlocked(op, config.DirectionBGLToWBGL, 1, types.Amount{}); reason == "" {
+		t.Errorf("route of paused WBGL return is not blocked")
+	}
+}
+
+func TestReturnRouteFromBinding(t *testing.T) {
+	setCaps(t, nil)
+	rec := &types.AddressBookRecord{SourceChain: 0, SourceAddress: "bgl1sender", DestChain: 1, DestAddress: "0xreceiver"}
+	if err := store.DB.UpsertAddressBookRecord(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	// earlier BGL return attempt cleared the destination chain
+	op := &types.BridgeOperation{SourceAddress: "bgl1sender", DestChain: 0}
+	if direction, chainId := returnRoute(op); direction != config.DirectionWBGLToBGL || chainId != 1 {
+		t.Errorf("returnRoute() = %s:%d", direction, chainId)
+	}
+}
+
+func TestPayoutAmount(t *testing.T) {
+	setCaps(t, nil)
+	config.Config.Fees = []config.FeeRule{{Bps: 100}}
+	config.Config.Dust.Policy = "revenue"
+
+	tests := []struct {
+		name string
+		op   *types.BridgeOperation
+		want string
+	}{
+		{"bgl to wbgl", &types.BridgeOperation{DestChain: 1, Amount: "100"}, "99"},
+		// 99.000000009 WBGL after fee, sub-satoshi dust is not paid out
+		{"wbgl to bgl", &types.BridgeOperation{SourceChain: 1, Amount: "100000000009090909090"}, "99"},
+		{"return", &types.BridgeOperation{SourceChain: 1, Amount: "100000000009090909090", ForceReturn: true}, "100.00000000909090909"},
+	}
+	for _, tt := range tests {
+		amount, err := payoutAmount(tt.op)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if amount.String() != tt.want {
+			t.Errorf("%s: payoutAmount() = %s, want %s", tt.name, amount, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package workers
+
+import (
+	"log"
+	"math/big"
+	"strings"
+
+	"gobglbridge/config"
+	"gobglbridge/store"
+	"gobglbridge/types"
+)
+
+// dust ledger accounts, per source EVM chain
+const (
+	dustRevenue  = "revenue"  // kept by the bridge
+	dustOwed     = "owed"     // waiting for refund, sum of all senders
+	dustRefunded = "refunded" // paid out with later transfers
+)
+
+// dust waiting for refund to a sender
+func dustOwedAccount(address string) string {
+	return dustOwed + ":" + strings.ToLower(address)
+}
+
+// splits BGL payout of WBGL to BGL operation into whole satoshis sent and dropped dust,
+// with refund policy sender's earlier dust is added once it reaches the threshold
+func splitDust(op *types.BridgeOperation, amount types.Amount) (send, dust, refund types.Amount, err error) {
+	send = amount.TruncateSatoshi()
+	dust = amount.Dust()
+	if op.Dust != "" {
+		// re-driven operation, booked by the first send already
+		if op.DustRefund != "" {
+			refund, err = types.ParseWei(op.DustRefund)
+		}
+		return send.Add(refund), dust, refund, err
+	}
+	if config.Config.Dust.Policy != "refund" {
+		return send, dust, types.Amount{}, nil
+	}
+
+	ledger, err := store.DB.GetDustLedger(op.SourceChain)
+	if err != nil {
+		return send, dust, types.Amount{}, err
+	}
+	owed := types.AmountFromWei(big.NewInt(ledger[dustOwedAccount(op.SourceAddress)])).Add(dust)
+	if owed.Wei().Int64() < config.Config.Dust.RefundThreshold {
+		return send, dust, types.Amount{}, nil
+	}
+	refund = owed.TruncateSatoshi()
+	return send.Add(refund), dust, refund, nil
+}
+
+// records dust on operation and ledger once the payout was sent
+func bookDust(op *types.BridgeOperation, dust, refund types.Amount) {
+	if op.Dust != "" {
+		return
+	}
+	op.Dust = dust.WeiString()
+	if !refund.IsZero() {
+		op.DustRefund = refund.WeiString()
+	}
+
+	var err error
+	if config.Config.Dust.Policy == "refund" {
+		delta := dust.Sub(refund).Wei().Int64()
+		err = store.DB.AddDust(op.SourceChain, dustOwedAccount(op.SourceAddress), delta)
+		if err == nil {
+			err = store.DB.AddDust(op.SourceChain, dustOwed, delta)
+		}
+		if err == nil && !refund.IsZero() {
+			err = store.DB.AddDust(op.SourceChain, dustRefunded, refund.Wei().Int64())
+		}
+	} else {
+		err = store.DB.AddDust(op.SourceChain, dustRevenue, dust.Wei().Int64())
+	}
+	if err != nil {
+		log.Printf("Error booking dust %s of bridge operation %s: %v", dust.WeiString(), op.ID, err)
+	}
+}
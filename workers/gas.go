@@ -0,0 +1,129 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"gobglbridge/EVMRPC"
+	"gobglbridge/EVMRPC/ierc20"
+	"gobglbridge/config"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// fees of a bridge tx, either legacy gas price or EIP-1559 tip and fee cap
+type txFees struct {
+	gasPrice *big.Int // nil for dynamic fee tx
+	tipCap   *big.Int
+	feeCap   *big.Int
+}
+
+func (f txFees) dynamic() bool {
+	return f.gasPrice == nil
+}
+
+// current fees by chain gas policy, dynamic if chain supports EIP-1559 and it is not disabled
+func suggestFees(ctx context.Context, chainId int) (txFees, error) {
+	policy := config.EVMChains[chainId].Gas
+	ceiling := policy.MaxPrice()
+
+	if !policy.Legacy {
+		head, err := EVMRPC.WithClient(
+			chainId, func(client *ethclient.Client) (*ethtypes.Header, error) {
+				return client.HeaderByNumber(ctx, nil)
+			},
+		)
+		if err != nil {
+			return txFees{}, err
+		}
+
+		if head.BaseFee != nil {
+			tip, err := EVMRPC.WithClient(
+				chainId, func(client *ethclient.Client) (*big.Int, error) {
+					return client.SuggestGasTipCap(ctx)
+				},
+			)
+			if err != nil {
+				return txFees{}, err
+			}
+			tip = percentOf(tip, policy.TipPercent)
+			// room for base fee to grow while tx waits for inclusion
+			feeCap := new(big.Int).Add(percentOf(head.BaseFee, policy.BaseFeePercent), tip)
+			feeCap = capFee(feeCap, ceiling)
+			return txFees{tipCap: capFee(tip, feeCap), feeCap: feeCap}, nil
+		}
+		// no base fee, chain does not support EIP-1559
+	}
+
+	gasPrice, err := EVMRPC.WithClient(
+		chainId, func(client *ethclient.Client) (*big.Int, error) {
+			return client.SuggestGasPrice(ctx)
+		},
+	)
+	if err != nil {
+		return txFees{}, err
+	}
+	// e.g. 200% on chains where suggested price is often too low
+	gasPrice = capFee(percentOf(gasPrice, policy.PricePercent), ceiling)
+	return txFees{gasPrice: gasPrice, tipCap: gasPrice, feeCap: gasPrice}, nil
+}
+
+// gas limit for custodian transfer of amount to address: estimate of the exact call plus margin
+func estimateTransferGas(ctx context.Context, chainId int, address string, amount *big.Int) (uint64, error) {
+	policy := config.EVMChains[chainId].Gas
+
+	parsed, err := abi.JSON(strings.NewReader(ierc20.Ierc20ABI))
+	if err != nil {
+		return 0, err
+	}
+	data, err := parsed.Pack("transfer", common.HexToAddress(address), amount)
+	if err != nil {
+		return 0, err
+	}
+	contract := common.HexToAddress(config.EVMChains[chainId].ContractAddress)
+
+	estimated, err := EVMRPC.WithClient(
+		chainId, func(client *ethclient.Client) (uint64, error) {
+			return client.EstimateGas(ctx, ethereum.CallMsg{
+				From: common.HexToAddress(config.Config.EVM.PublicAddress),
+				To:   &contract,
+				Data: data,
+			})
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	gasLimit := estimated * uint64(100+policy.LimitMarginPercent) / 100
+	if gasLimit > policy.MaxGasLimit {
+		return 0, fmt.Errorf("estimated gas %d exceeds %s cap %d", gasLimit, config.EVMChains[chainId].Name, policy.MaxGasLimit)
+	}
+	return gasLimit, nil
+}
+
+func percentOf(v *big.Int, percent int) *big.Int {
+	res := new(big.Int).Mul(v, big.NewInt(int64(percent)))
+	return res.Div(res, big.NewInt(100))
+}
+
+// lower of fee and ceiling, ceiling may be nil
+func capFee(fee, ceiling *big.Int) *big.Int {
+	if ceiling != nil && fee.Cmp(ceiling) > 0 {
+		return new(big.Int).Set(ceiling)
+	}
+	return fee
+}
+
+func maxFee(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
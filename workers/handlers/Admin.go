@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gobglbridge/admin"
+	"gobglbridge/config"
+	"gobglbridge/store"
+	"gobglbridge/types"
+
+	"github.com/go-chi/chi"
+)
+
+// admin endpoints require Authorization: Bearer <admin_token>, disabled if token is not configured
+func AdminAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := config.Config.Server.AdminToken
+		if token == "" {
+			responseJSON(w, &APIResponse{
+				Status:  "error",
+				Message: "Admin API is disabled",
+			}, http.StatusForbidden)
+			return
+		}
+
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			log.Printf("Unauthorized admin request %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			responseJSON(w, &APIResponse{
+				Status:  "error",
+				Message: "Unauthorized",
+			}, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// who made the change for audit log, optional X-Operator header names the person
+func operator(r *http.Request) string {
+	name := r.Header.Get("X-Operator")
+	if name == "" {
+		name = "admin"
+	}
+	return fmt.Sprintf("%s@%s", name, r.RemoteAddr)
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %s", err.Error())
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Message: "Error reading request body",
+		}, http.StatusBadRequest)
+		return false
+	}
+
+	if err := json.Unmarshal(body, req); err != nil {
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Message: "Cannot unmarshal input JSON",
+		}, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+type AdminReasonRequest struct {
+	Reason string `json:"reason"`
+}
+
+// reason is required for every operator decision, false if response is already written
+func readReason(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req AdminReasonRequest
+	if !readJSON(w, r, &req) {
+		return "", false
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Field:   "reason",
+			Message: "Reason is required",
+		}, http.StatusBadRequest)
+		return "", false
+	}
+	return reason, true
+}
+
+func responseAdmin(w http.ResponseWriter, data interface{}, err error) {
+	switch {
+	case err == nil:
+		responseJSON(w, data, http.StatusOK)
+	case errors.Is(err, admin.ErrNotFound):
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Message: "Bridge operation not found",
+		}, http.StatusNotFound)
+	case errors.Is(err, admin.ErrNotAllowed), errors.Is(err, types.ErrStatusConflict):
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, http.StatusConflict)
+	default:
+		log.Printf("Error in admin action: %s", err.Error())
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Message: "Error saving changes",
+		}, http.StatusInternalServerError)
+	}
+}
+
+// full records incl. messages, e.g. /admin/ops?status=failed
+func AdminGetOperations(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if _, ok := config.RedisStatusSets[status]; !ok {
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Field:   "status",
+			Message: "Unknown status",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	ops, err := store.DB.FindAllBridgeOperationsByStatus(status)
+	responseAdmin(w, ops, err)
+}
+
+// by ID, deposit tx hash or payout tx hash
+func AdminGetOperation(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "id")
+	op, err := store.DB.FindBridgeOperationByID(key)
+	if err == nil && op == nil {
+		op, err = store.DB.FindBridgeOperationSourceTxHash(key)
+	}
+	if err == nil && op == nil {
+		op, err = store.DB.FindBridgeOperationDestinationTxHash(key)
+	}
+	if err == nil && op == nil {
+		err = admin.ErrNotFound
+	}
+	responseAdmin(w, op, err)
+}
+
+// back to pending, executor bridges it again
+func RequeueOperation(w http.ResponseWriter, r *http.Request) {
+	if reason, ok := readReason(w, r); ok {
+		op, err := admin.Requeue(chi.URLParam(r, "id"), operator(r), reason)
+		responseAdmin(w, op, err)
+	}
+}
+
+// back to pending, executor returns funds to the sender
+func ReturnOperation(w http.ResponseWriter, r *http.Request) {
+	if reason, ok := readReason(w, r); ok {
+		op, err := admin.ForceReturn(chi.URLParam(r, "id"), operator(r), reason)
+		responseAdmin(w, op, err)
+	}
+}
+
+// settled outside of the bridge, reason is the note
+func ResolveOperation(w http.ResponseWriter, r *http.Request) {
+	if reason, ok := readReason(w, r); ok {
+		op, err := admin.Resolve(chi.URLParam(r, "id"), operator(r), reason)
+		responseAdmin(w, op, err)
+	}
+}
+
+type AdminBindingRequest struct {
+	Direction  string `json:"direction"` // "bgl_to_wbgl": BGL deposit address to EVM address, "wbgl_to_bgl" the other way
+	Chain      string `json:"chain"`
+	BGLAddress string `json:"bglAddress"`
+	EthAddress string `json:"ethAddress"`
+	Reason     string `json:"reason"`
+}
+
+// creates binding or repairs the one with the same source address
+func UpsertBinding(w http.ResponseWriter, r *http.Request) {
+	var req AdminBindingRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	chainConfig, ok := config.ChainByCode(req.Chain)
+	if !ok {
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Field:   "chain",
+			Message: "EVM chain not provided or not supported",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	var rec types.AddressBookRecord
+	switch req.Direction {
+	case config.DirectionBGLToWBGL:
+		rec = types.AddressBookRecord{
+			SourceChain:   0,
+			SourceAddress: req.BGLAddress,
+			DestChain:     chainConfig.ChainID,
+			DestAddress:   req.EthAddress,
+		}
+	case config.DirectionWBGLToBGL:
+		rec = types.AddressBookRecord{
+			SourceChain:   chainConfig.ChainID,
+			SourceAddress: req.EthAddress,
+			DestChain:     0,
+			DestAddress:   req.BGLAddress,
+		}
+	default:
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Field:   "direction",
+			Message: "Direction must be bgl_to_wbgl or wbgl_to_bgl",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	err := admin.UpsertBinding(&rec, operator(r), req.Reason)
+	if errors.Is(err, admin.ErrNotAllowed) {
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+	responseAdmin(w, &rec, err)
+}
+
+// newest first, e.g. /admin/audit?limit=50
+func GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	entries, err := store.DB.GetAuditLog(limit)
+	responseAdmin(w, entries, err)
+}
+
+// all records, or the ones with address on either side, e.g. /admin/bindings?address=0x...
+func AdminGetBindings(w http.ResponseWriter, r *http.Request) {
+	recs, err := store.DB.FindAllAddressBookRecords()
+	if err != nil {
+		responseAdmin(w, nil, err)
+		return
+	}
+
+	address := r.URL.Query().Get("address")
+	found := make([]*types.AddressBookRecord, 0, len(recs))
+	for _, rec := range recs {
+		if address == "" || strings.EqualFold(rec.SourceAddress, address) || strings.EqualFold(rec.DestAddress, address) {
+			found = append(found, rec)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].TsCreated < found[j].TsCreated })
+	responseAdmin(w, found, nil)
+}
+
+type AdminCheckpoint struct {
+	Chain string `json:"chain"` // "bgl" or EVM chain code
+	Block string `json:"block"` // BGL block hash or EVM block height
+}
+
+// scan checkpoints, BGL first
+func AdminGetCheckpoints(w http.ResponseWriter, r *http.Request) {
+	blockHash, err := store.DB.GetBGLScannedBlock()
+	if err != nil {
+		responseAdmin(w, nil, err)
+		return
+	}
+	checkpoints := []AdminCheckpoint{{Chain: "bgl", Block: blockHash}}
+	for _, chainId := range config.ChainIDs() {
+		height, err := store.DB.GetEVMScannedBlock(chainId)
+		if err != nil {
+			responseAdmin(w, nil, err)
+			return
+		}
+		checkpoints = append(checkpoints, AdminCheckpoint{Chain: config.EVMChains[chainId].ShortCode, Block: strconv.Itoa(height)})
+	}
+	responseAdmin(w, checkpoints, nil)
+}
+
+type AdminCheckpointRequest struct {
+	Block  string `json:"block"`
+	Reason string `json:"reason"`
+}
+
+// resets scan checkpoint of /admin/checkpoints/{code}, scanner picks it up on its next round
+func SetCheckpoint(w http.ResponseWriter, r *http.Request) {
+	var req AdminCheckpointRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	code := chi.URLParam(r, "code")
+	var err error
+	if strings.EqualFold(code, "bgl") {
+		err = admin.SetBGLCheckpoint(req.Block, operator(r), req.Reason)
+	} else if chainConfig, ok := config.ChainByCode(code); !ok {
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Message: "EVM chain not supported",
+		}, http.StatusNotFound)
+		return
+	} else if height, convErr := strconv.Atoi(req.Block); convErr != nil || height < 0 {
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Field:   "block",
+			Message: "Block height expected",
+		}, http.StatusBadRequest)
+		return
+	} else {
+		err = admin.SetEVMCheckpoint(chainConfig.ChainID, height, operator(r), req.Reason)
+	}
+
+	if errors.Is(err, admin.ErrNotAllowed) {
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+	responseAdmin(w, &APIResponse{Status: "ok"}, err)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"gobglbridge/admin"
+	"gobglbridge/store"
+
+	"github.com/go-chi/chi"
+)
+
+// operations with amount above maximum, full records incl. messages
+func GetApprovals(w http.ResponseWriter, r *http.Request) {
+	ops, err := store.DB.FindAllBridgeOperationsByStatus("awaiting_approval")
+	if err != nil {
+		log.Printf("Error getting bridge operations: %s", err.Error())
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Message: "Error getting bridge operations",
+		}, http.StatusInternalServerError)
+		return
+	}
+	responseJSON(w, ops, http.StatusOK)
+}
+
+// approved operation goes back to pending and is bridged regardless of maximum
+func ApproveOperation(w http.ResponseWriter, r *http.Request) {
+	if reason, ok := readReason(w, r); ok {
+		op, err := admin.Approve(chi.URLParam(r, "id"), operator(r), reason)
+		responseAdmin(w, op, err)
+	}
+}
+
+// rejected operation goes back to pending and executor returns funds to the sender
+func RejectOperation(w http.ResponseWriter, r *http.Request) {
+	if reason, ok := readReason(w, r); ok {
+		op, err := admin.Reject(chi.URLParam(r, "id"), operator(r), reason)
+		responseAdmin(w, op, err)
+	}
+}
@@ -12,22 +12,16 @@ import (
 	"gobglbridge/config"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-chi/chi"
 )
 
-func BalanceEth(w http.ResponseWriter, r *http.Request) {
-	BalanceEVM(w, r, 1)
-}
-
-func BalanceOP(w http.ResponseWriter, r *http.Request) {
-	BalanceEVM(w, r, 10)
-}
-
-func BalanceBNB(w http.ResponseWriter, r *http.Request) {
-	BalanceEVM(w, r, 56)
-}
-
-func BalanceArb(w http.ResponseWriter, r *http.Request) {
-	BalanceEVM(w, r, 42161)
+func BalanceChain(w http.ResponseWriter, r *http.Request) {
+	chain, ok := config.ChainByCode(chi.URLParam(r, "chain"))
+	if !ok {
+		responsePlain(w, []byte("unknown chain"), http.StatusNotFound)
+		return
+	}
+	BalanceEVM(w, r, chain.ChainID)
 }
 
 func BalanceEVM(w http.ResponseWriter, r *http.Request, chainId int) {
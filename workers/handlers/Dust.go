@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"gobglbridge/config"
+	"gobglbridge/store"
+)
+
+// sub-satoshi dust ledger (wei) per EVM chain code, aggregate accounts only
+func GetDustLedger(w http.ResponseWriter, r *http.Request) {
+	responseDustLedgers(w, false)
+}
+
+// dust ledger with amounts owed to each sender (owed:<address>), see admin API
+func AdminGetDustLedger(w http.ResponseWriter, r *http.Request) {
+	responseDustLedgers(w, true)
+}
+
+func responseDustLedgers(w http.ResponseWriter, perSender bool) {
+	ledgers := map[string]map[string]int64{}
+	for _, chainId := range config.ChainIDs() {
+		ledger, err := store.DB.GetDustLedger(chainId)
+		if err != nil {
+			log.Printf("Error getting dust ledger: %s", err.Error())
+			responseJSON(w, &APIResponse{
+				Status:  "error",
+				Message: "Error getting dust ledger",
+			}, http.StatusInternalServerError)
+			return
+		}
+		if !perSender {
+			for account := range ledger {
+				if strings.Contains(account, ":") {
+					delete(ledger, account)
+				}
+			}
+		}
+		ledgers[config.EVMChains[chainId].ShortCode] = ledger
+	}
+
+	responseJSON(w, ledgers, http.StatusOK)
+}
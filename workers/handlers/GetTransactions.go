@@ -1,13 +1,13 @@
 package handlers
 
 import (
-	"gobglbridge/redis"
+	"gobglbridge/store"
 	"net/http"
 )
 
 func GetFailedTransactions(w http.ResponseWriter, r *http.Request) {
 
-	failedTxs, err := redis.FindAllBridgeOperationsByStatus("failed")
+	failedTxs, err := store.DB.FindAllBridgeOperationsByStatus("failed")
 
 	if err != nil {
 		responseJSON(w, nil, 500)
@@ -19,7 +19,7 @@ func GetFailedTransactions(w http.ResponseWriter, r *http.Request) {
 
 func GetReturnFailTransactions(w http.ResponseWriter, r *http.Request) {
 
-	failedTxs, err := redis.FindAllBridgeOperationsByStatus("returnfail")
+	failedTxs, err := store.DB.FindAllBridgeOperationsByStatus("returnfail")
 
 	if err != nil {
 		responseJSON(w, nil, 500)
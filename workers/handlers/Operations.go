@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"gobglbridge/store"
+	"gobglbridge/types"
+
+	"github.com/go-chi/chi"
+)
+
+// only fields safe to show to anyone, internal messages are never exposed
+func publicBridgeOperation(op *types.BridgeOperation) *APIBridgeOperation {
+	return &APIBridgeOperation{
+		ID:            op.ID,
+		Status:        op.Status,
+		SourceChain:   op.SourceChain,
+		DestChain:     op.DestChain,
+		TsFound:       op.TsFound,
+		TsUpdated:     op.TsUpdated,
+		Amount:        op.Amount,
+		SourceAddress: op.SourceAddress,
+		DestAddress:   op.DestAddress,
+		SourceTxHash:  op.SourceTxHash,
+		DestTxHash:    op.DestTxHash,
+		BindingID:     op.BindingID,
+	}
+}
+
+func responseOperation(w http.ResponseWriter, op *types.BridgeOperation, err error) {
+	if err != nil {
+		log.Printf("Error getting bridge operation: %s", err.Error())
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Message: "Error getting bridge operation",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	if op == nil {
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Message: "Bridge operation not found",
+		}, http.StatusNotFound)
+		return
+	}
+
+	responseJSON(w, publicBridgeOperation(op), http.StatusOK)
+}
+
+func GetOperation(w http.ResponseWriter, r *http.Request) {
+	op, err := store.DB.FindBridgeOperationByID(chi.URLParam(r, "id"))
+	responseOperation(w, op, err)
+}
+
+// tx hash can be either the user's deposit or the bridge payout
+func GetOperationByTxHash(w http.ResponseWriter, r *http.Request) {
+	txHash := chi.URLParam(r, "txHash")
+
+	op, err := store.DB.FindBridgeOperationSourceTxHash(txHash)
+	if err == nil && op == nil {
+		op, err = store.DB.FindBridgeOperationDestinationTxHash(txHash)
+	}
+	responseOperation(w, op, err)
+}
+
+func responseOperations(w http.ResponseWriter, ops []*types.BridgeOperation, err error) {
+	if err != nil {
+		log.Printf("Error getting bridge operations: %s", err.Error())
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Message: "Error getting bridge operations",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	res := make([]*APIBridgeOperation, 0, len(ops))
+	for _, op := range ops {
+		res = append(res, publicBridgeOperation(op))
+	}
+	responseJSON(w, res, http.StatusOK)
+}
+
+func GetOperationsByAddress(w http.ResponseWriter, r *http.Request) {
+	ops, err := store.DB.FindBridgeOperationsByAddress(chi.URLParam(r, "address"))
+	responseOperations(w, ops, err)
+}
+
+// deposits made to an address returned by /submit/bgl or /submit/wbgl (by its id)
+func GetOperationsByBinding(w http.ResponseWriter, r *http.Request) {
+	ops, err := store.DB.FindBridgeOperationsByBinding(chi.URLParam(r, "id"))
+	responseOperations(w, ops, err)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gobglbridge/config"
+	"gobglbridge/fees"
+	"gobglbridge/types"
+)
+
+// fee quote, e.g. /quote?direction=bgl_to_wbgl&chain=eth&amount=100.5 (amount in BGL)
+func GetQuote(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	direction := query.Get("direction")
+	if direction != config.DirectionBGLToWBGL && direction != config.DirectionWBGLToBGL {
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Field:   "direction",
+			Message: "Direction must be bgl_to_wbgl or wbgl_to_bgl",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	chainConfig, ok := config.ChainByCode(query.Get("chain"))
+	if !ok {
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Field:   "chain",
+			Message: "Unknown chain",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	amount, err := types.ParseBGL(query.Get("amount"))
+	if err != nil {
+		responseJSON(w, &APIResponse{
+			Status:  "error",
+			Field:   "amount",
+			Message: "Amount must be a BGL decimal with up to 8 decimals",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	quote := fees.Compute(direction, chainConfig.ChainID, amount)
+	limits := fees.Limit(direction, chainConfig.ChainID)
+	receive := quote.Receive
+	if direction == config.DirectionWBGLToBGL {
+		// BGL carries whole satoshis only
+		receive = receive.TruncateSatoshi()
+	}
+
+	responseJSON(w, &APIQuoteResponse{
+		Status:        "ok",
+		Amount:        amount.BGLString(),
+		Fee:           quote.Fee.BGLString(),
+		Receive:       receive.BGLString(),
+		FeePercentage: fees.Percentage(quote.Rule),
+		FeeMin:        quote.Rule.Min,
+		FeeMax:        quote.Rule.Max,
+		FeeFlat:       quote.Rule.Flat,
+		MinAmount:     limits.Rule.Min,
+		MaxAmount:     limits.Rule.Max,
+	}, http.StatusOK)
+}
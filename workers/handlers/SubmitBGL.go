@@ -2,10 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"gobglbridge/BGLRPC"
 	"gobglbridge/config"
-	"gobglbridge/redis"
+	"gobglbridge/fees"
+	"gobglbridge/store"
 	"gobglbridge/types"
 	"io/ioutil"
 	"log"
@@ -54,8 +54,8 @@ func SubmitBGL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// TODO replace with config evms list check
-	if req.Chain != "eth" && req.Chain != "bnb" && req.Chain != "arb" && req.Chain != "op" {
+	chainConfig, ok := config.ChainByCode(req.Chain)
+	if !ok {
 		responseJSON(w, &APIResponse{
 			Status:  "error",
 			Field:   "chain",
@@ -74,15 +74,7 @@ func SubmitBGL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	chain := 1
-	// for compatibility with current
-	if req.Chain == "arb" {
-		chain = 42161
-	} else if req.Chain == "bnb" {
-		chain = 56
-	} else if req.Chain == "op" {
-		chain = 10
-	}
+	chain := chainConfig.ChainID
 
 	rec := types.AddressBookRecord{
 		SourceChain:   0,
@@ -92,7 +84,7 @@ func SubmitBGL(w http.ResponseWriter, r *http.Request) {
 		TsCreated:     time.Now().Unix(),
 	}
 
-	err = redis.UpsertAddressBookRecord(&rec)
+	err = store.DB.UpsertAddressBookRecord(&rec)
 	if err != nil {
 		log.Printf("Error storing address book record: %s\n", err.Error())
 		responseJSON(w, &APIResponse{
@@ -115,12 +107,19 @@ func SubmitBGL(w http.ResponseWriter, r *http.Request) {
 		balanceFloat, _ = balanceBF.Float64()
 	}
 
+	fee := fees.Rule(config.DirectionBGLToWBGL, chain)
+	limits := fees.Limit(config.DirectionBGLToWBGL, chain)
 	//ctx := context.Background()
 	responseJSON(w, &APIResponseAddressBook{
 		Status:        "ok",
 		ID:            rec.ID,
 		BGLAddress:    BGLaddress,
 		Balance:       balanceFloat,
-		FeePercentage: fmt.Sprintf("%d", config.Config.FeePercentage),
+		FeePercentage: fees.Percentage(fee),
+		FeeMin:        fee.Min,
+		FeeMax:        fee.Max,
+		FeeFlat:       fee.Flat,
+		MinAmount:     limits.Rule.Min,
+		MaxAmount:     limits.Rule.Max,
 	}, http.StatusOK)
 }
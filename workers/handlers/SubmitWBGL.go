@@ -6,7 +6,8 @@ import (
 	"fmt"
 	"gobglbridge/BGLRPC"
 	"gobglbridge/config"
-	"gobglbridge/redis"
+	"gobglbridge/fees"
+	"gobglbridge/store"
 	"gobglbridge/types"
 	"io/ioutil"
 	"log"
@@ -79,8 +80,8 @@ func SubmitWBGL(w http.ResponseWriter, r *http.Request) {
 		}
 	*/
 
-	// TODO replace with config evms list check
-	if req.Chain != "eth" && req.Chain != "bnb" && req.Chain != "arb" && req.Chain != "op" {
+	chainConfig, ok := config.ChainByCode(req.Chain)
+	if !ok {
 		responseJSON(w, &APIResponse{
 			Status:  "error",
 			Field:   "chain",
@@ -111,15 +112,7 @@ func SubmitWBGL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	chain := 1
-	// for compatibility with current
-	if req.Chain == "arb" {
-		chain = 42161
-	} else if req.Chain == "bnb" {
-		chain = 56
-	} else if req.Chain == "op" {
-		chain = 10
-	}
+	chain := chainConfig.ChainID
 
 	rec := types.AddressBookRecord{
 		SourceChain:   chain,
@@ -129,7 +122,7 @@ func SubmitWBGL(w http.ResponseWriter, r *http.Request) {
 		TsCreated:     time.Now().Unix(),
 	}
 
-	err = redis.UpsertAddressBookRecord(&rec)
+	err = store.DB.UpsertAddressBookRecord(&rec)
 	if err != nil {
 		log.Printf("Error storing address book record: %s\n", err.Error())
 		responseJSON(w, &APIResponse{
@@ -148,13 +141,20 @@ func SubmitWBGL(w http.ResponseWriter, r *http.Request) {
 		// continue processing nonetheless
 	}
 
+	fee := fees.Rule(config.DirectionWBGLToBGL, chain)
+	limits := fees.Limit(config.DirectionWBGLToBGL, chain)
 	//ctx := context.Background()
 	responseJSON(w, &APIResponseAddressBook{
 		Status:        "ok",
 		ID:            rec.ID,
 		Address:       config.Config.EVM.PublicAddress,
 		Balance:       balanceBGL,
-		FeePercentage: fmt.Sprintf("%d", config.Config.FeePercentage),
+		FeePercentage: fees.Percentage(fee),
+		FeeMin:        fee.Min,
+		FeeMax:        fee.Max,
+		FeeFlat:       fee.Flat,
+		MinAmount:     limits.Rule.Min,
+		MaxAmount:     limits.Rule.Max,
 	}, http.StatusOK)
 }
 
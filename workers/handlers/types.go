@@ -11,12 +11,48 @@ type APIResponseAddressBook struct {
 	ID            string  `json:"id"`
 	Balance       float64 `json:"balance"`
 	FeePercentage string  `json:"feePercentage"`
+	// absolute fee parts in BGL, see config.FeeRule
+	FeeMin  string `json:"feeMin,omitempty"`
+	FeeMax  string `json:"feeMax,omitempty"`
+	FeeFlat string `json:"feeFlat,omitempty"`
+	// deposit limits in BGL, see config.LimitRule
+	MinAmount string `json:"minAmount,omitempty"`
+	MaxAmount string `json:"maxAmount,omitempty"`
 	// either BGL or WBGL address to send to
 	Address    string `json:"address,omitempty"`
 	BGLAddress string `json:"bglAddress,omitempty"`
 }
 
+type APIQuoteResponse struct {
+	Status        string `json:"status"`
+	Amount        string `json:"amount"`
+	Fee           string `json:"fee"`
+	Receive       string `json:"receive"`
+	FeePercentage string `json:"feePercentage"`
+	FeeMin        string `json:"feeMin,omitempty"`
+	FeeMax        string `json:"feeMax,omitempty"`
+	FeeFlat       string `json:"feeFlat,omitempty"`
+	MinAmount     string `json:"minAmount,omitempty"`
+	MaxAmount     string `json:"maxAmount,omitempty"`
+}
+
 type APIStateResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
 }
+
+// public view of types.BridgeOperation
+type APIBridgeOperation struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	SourceChain   int    `json:"sourceChain"`
+	DestChain     int    `json:"destChain"`
+	TsFound       int64  `json:"tsFound"`
+	TsUpdated     int64  `json:"tsUpdated"`
+	Amount        string `json:"amount"`
+	SourceAddress string `json:"sourceAddress"`
+	DestAddress   string `json:"destAddress"`
+	SourceTxHash  string `json:"sourceTxHash"`
+	DestTxHash    string `json:"destTxHash"`
+	BindingID     string `json:"bindingId"`
+}
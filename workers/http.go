@@ -3,13 +3,10 @@ package workers
 import (
 	"context"
 	"crypto/tls"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
-	"os/signal"
-	"path/filepath"
-	"strings"
-	"syscall"
 	"time"
 
 	"gobglbridge/config"
@@ -17,9 +14,10 @@ import (
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func Worker_HTTP() {
+func Worker_HTTP(ctx context.Context, appFS fs.FS) {
 	log.Printf("Starting HTTP service")
 
 	r := chi.NewRouter()
@@ -31,40 +29,54 @@ func Worker_HTTP() {
 
 	r.Get("/balance/bgl", handlers.BalanceBGL)
 
-	r.Get("/balance/eth", handlers.BalanceEth)
-	r.Get("/balance/bnb", handlers.BalanceBNB)
-	r.Get("/balance/op", handlers.BalanceOP)
-	r.Get("/balance/arb", handlers.BalanceArb)
+	// EVM chains by config short code, e.g. /balance/eth
+	r.Get("/balance/{chain}", handlers.BalanceChain)
 
 	r.Post("/submit/bgl", handlers.SubmitBGL)
 	r.Post("/submit/wbgl", handlers.SubmitWBGL)
 
 	r.Get("/stats/failed", handlers.GetFailedTransactions)
 	r.Get("/stats/returnfail", handlers.GetReturnFailTransactions)
-
-	// a bit of logic to prevent directory listing
-	r.Get(
-		"/*", func(w http.ResponseWriter, r *http.Request) {
-			workDir, _ := os.Getwd()
-			filesDir := filepath.Join(workDir, "app")
-			filePath := filepath.Join(filesDir, strings.Replace(r.URL.Path, "\\", "/", -1))
-
-			fileInfo, err := os.Stat(filePath)
-			if err != nil || fileInfo.IsDir() {
-				filePath = filepath.Join(filesDir, "index.html")
-				fileInfo, _ = os.Stat(filePath)
-			}
-
-			file, err := os.Open(filePath)
-			if err != nil {
-				// this should not happen at this point
-				http.Error(w, "unable to open", http.StatusInternalServerError)
-				return
-			}
-
-			http.ServeContent(w, r, file.Name(), fileInfo.ModTime(), file)
-		},
-	)
+	r.Get("/stats/dust", handlers.GetDustLedger)
+	r.Get("/quote", handlers.GetQuote)
+
+	r.Get("/ops/{id}", handlers.GetOperation)
+	r.Get("/ops/tx/{txHash}", handlers.GetOperationByTxHash)
+	r.Get("/ops/address/{address}", handlers.GetOperationsByAddress)
+	r.Get("/ops/binding/{id}", handlers.GetOperationsByBinding)
+
+	r.Handle("/metrics", promhttp.Handler())
+
+	// operator endpoints, Authorization: Bearer <admin_token>
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(handlers.AdminAuth)
+		r.Get("/approvals", handlers.GetApprovals)
+		r.Post("/approvals/{id}/approve", handlers.ApproveOperation)
+		r.Post("/approvals/{id}/reject", handlers.RejectOperation)
+
+		r.Get("/ops", handlers.AdminGetOperations)
+		r.Get("/ops/{id}", handlers.AdminGetOperation)
+		r.Post("/ops/{id}/requeue", handlers.RequeueOperation)
+		r.Post("/ops/{id}/return", handlers.ReturnOperation)
+		r.Post("/ops/{id}/resolve", handlers.ResolveOperation)
+		r.Get("/bindings", handlers.AdminGetBindings)
+		r.Post("/bindings", handlers.UpsertBinding)
+		r.Get("/checkpoints", handlers.AdminGetCheckpoints)
+		r.Post("/checkpoints/{code}", handlers.SetCheckpoint)
+		r.Get("/audit", handlers.GetAuditLog)
+		r.Get("/dust", handlers.AdminGetDustLedger)
+	})
+
+	// web app is embedded into the binary, unless overridden from disk
+	if config.Config.Server.AppDir != "" {
+		log.Printf("Serving web app from %s", config.Config.Server.AppDir)
+		appFS = os.DirFS(config.Config.Server.AppDir)
+	}
+	app, err := newStaticApp(appFS)
+	if err != nil {
+		log.Fatalf("error loading web app: %s", err)
+	}
+	r.Get("/*", app.ServeHTTP)
 
 	var server *http.Server
 
@@ -85,9 +97,6 @@ func Worker_HTTP() {
 		}
 	}
 
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-
 	go func() {
 		if config.Config.Server.UseSSL {
 			if err := server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
@@ -101,21 +110,19 @@ func Worker_HTTP() {
 	}()
 	log.Print("HTTP service started")
 
-	<-done
+	<-ctx.Done()
 	log.Print("HTTP service stopped")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer func() {
 		cancel()
 	}()
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("HTTP service shutdown error: %+v", err)
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP service shutdown error: %+v", err)
+		return
 	}
 	log.Print("HTTP service shutdown normal")
-
-	// send signal to other threads/workers to exit
-	WorkerShutdown = true
 }
 
 func CORSHeaders(w http.ResponseWriter, r *http.Request) {
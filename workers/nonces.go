@@ -0,0 +1,193 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"gobglbridge/EVMRPC"
+	"gobglbridge/config"
+	"gobglbridge/store"
+	"gobglbridge/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// custodian nonces are reserved from the store (executor never asks a possibly lagging RPC);
+// this worker keeps them in sync with the chain and fills gaps left by abandoned txs
+func Worker_nonces(ctx context.Context) {
+	// lowest unconfirmed nonce per chain and since when it is stuck
+	stuckSince := map[int]time.Time{}
+	stuckNonce := map[int]uint64{}
+
+	for sleep(ctx, time.Minute) {
+		for _, chainId := range config.ChainIDs() {
+			next, confirmed, pending, err := reconcileNonce(ctx, chainId)
+			if err != nil {
+				log.Printf("Error reconciling %s nonce: %v", config.EVMChains[chainId].Name, err)
+				continue
+			}
+			// nothing reserved is waiting, or the first waiting tx is in mempool
+			if confirmed >= next || pending > confirmed {
+				delete(stuckSince, chainId)
+				continue
+			}
+			if stuckNonce[chainId] != confirmed || stuckSince[chainId].IsZero() {
+				stuckNonce[chainId] = confirmed
+				stuckSince[chainId] = time.Now()
+				continue
+			}
+			if time.Since(stuckSince[chainId]) < time.Duration(config.Config.EVM.DropTimeout)*time.Minute {
+				continue
+			}
+
+			err = fillNonceGap(ctx, chainId, confirmed)
+			if err != nil {
+				log.Printf("Error filling %s nonce %d: %v", config.EVMChains[chainId].Name, confirmed, err)
+				continue
+			}
+			delete(stuckSince, chainId)
+		}
+	}
+}
+
+// syncs stored nonces with chains before executor starts reserving them
+func ReconcileNonces(ctx context.Context) {
+	for _, chainId := range config.ChainIDs() {
+		if _, _, _, err := reconcileNonce(ctx, chainId); err != nil {
+			log.Printf("Error reconciling %s nonce: %v", config.EVMChains[chainId].Name, err)
+		}
+	}
+}
+
+// adopts chain nonce when it is ahead of stored one (initialization, txs sent outside of bridge),
+// returns next nonce to reserve, nonce of the first unconfirmed tx and next nonce by mempool
+func reconcileNonce(ctx context.Context, chainId int) (uint64, uint64, uint64, error) {
+	custodian := common.HexToAddress(config.Config.EVM.PublicAddress)
+
+	var confirmed, pending uint64
+	_, err := EVMRPC.WithClient(
+		chainId, func(client *ethclient.Client) (bool, error) {
+			var err error
+			confirmed, err = client.NonceAt(ctx, custodian, nil)
+			if err != nil {
+				return false, err
+			}
+			pending, err = client.PendingNonceAt(ctx, custodian)
+			return err == nil, err
+		},
+	)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	for {
+		stored, err := store.DB.GetEVMNonce(chainId)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if stored >= int64(pending) {
+			return uint64(stored), confirmed, pending, nil
+		}
+
+		swapped, err := store.DB.SwapEVMNonce(chainId, stored, int64(pending))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if swapped {
+			if stored != -1 {
+				log.Printf("%s custodian nonce %d is behind chain, moved to %d", config.EVMChains[chainId].Name, stored, pending)
+			}
+			return pending, confirmed, pending, nil
+		}
+	}
+}
+
+// nonce for a new custodian tx, released by releaseNonce if tx was never broadcast
+func reserveNonce(chainId int) (uint64, error) {
+	nonce, err := store.DB.ReserveEVMNonce(chainId)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(nonce), nil
+}
+
+// gives back nonce if no later one was reserved meanwhile, otherwise the gap is filled by Worker_nonces
+func releaseNonce(chainId int, nonce uint64) {
+	_, err := store.DB.SwapEVMNonce(chainId, int64(nonce)+1, int64(nonce))
+	if err != nil {
+		log.Printf("Error releasing %s nonce %d: %v", config.EVMChains[chainId].Name, nonce, err)
+	}
+}
+
+// sends zero value self transfer with nonce that blocks later txs and no operation is going to use
+func fillNonceGap(ctx context.Context, chainId int, nonce uint64) error {
+	// re-driven operations resend with their pinned nonce; a finished one requeued later
+	// has its re-signed tx caught as dropped with nonce reused and gets a fresh nonce
+	for _, status := range []string{"pending", "executing", "returning"} {
+		ops, err := store.DB.FindAllBridgeOperationsByStatus(status)
+		if err != nil {
+			return err
+		}
+		for _, op := range ops {
+			if op.DestTxNonce != nil && *op.DestTxNonce == nonce && nonceChain(op) == chainId {
+				return nil
+			}
+		}
+	}
+
+	fees, err := suggestFees(ctx, chainId)
+	if err != nil {
+		return err
+	}
+	custodian := common.HexToAddress(config.Config.EVM.PublicAddress)
+	var txData ethtypes.TxData
+	if fees.dynamic() {
+		txData = &ethtypes.DynamicFeeTx{
+			ChainID:   big.NewInt(int64(chainId)),
+			Nonce:     nonce,
+			GasTipCap: fees.tipCap,
+			GasFeeCap: fees.feeCap,
+			Gas:       21000,
+			To:        &custodian,
+			Value:     big.NewInt(0),
+		}
+	} else {
+		txData = &ethtypes.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: fees.gasPrice,
+			Gas:      21000,
+			To:       &custodian,
+			Value:    big.NewInt(0),
+		}
+	}
+
+	privateKey, err := crypto.HexToECDSA(config.Config.EVM.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("error instantiating private key: %s", err)
+	}
+	tx, err := ethtypes.SignNewTx(privateKey, ethtypes.LatestSignerForChainID(big.NewInt(int64(chainId))), txData)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Filling %s nonce gap %d with self transfer %s", config.EVMChains[chainId].Name, nonce, tx.Hash().Hex())
+	return broadcastEVMTx(ctx, chainId, tx)
+}
+
+// chain the pinned destination nonce is going to be used on, 0 if none;
+// pending WBGL to BGL operation re-driven from a failed return has DestChain reset
+func nonceChain(op *types.BridgeOperation) int {
+	if op.DestChain > 0 {
+		return op.DestChain
+	}
+	if op.Status == "pending" {
+		return op.SourceChain
+	}
+	return 0
+}
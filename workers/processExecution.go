@@ -2,10 +2,10 @@ package workers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
-	"strconv"
 	"strings"
 	"time"
 
@@ -14,30 +14,38 @@ import (
 	"gobglbridge/EVMRPC"
 	"gobglbridge/EVMRPC/ierc20"
 	"gobglbridge/config"
-	"gobglbridge/redis"
+	"gobglbridge/fees"
+	"gobglbridge/metrics"
+	"gobglbridge/store"
 	"gobglbridge/types"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
-func Worker_processExecution() {
-	for !WorkerShutdown {
-		time.Sleep(3 * time.Second)
+func Worker_processExecution(ctx context.Context) {
+	for sleep(ctx, 3*time.Second) {
 
-		pending, err := redis.FindBridgeOperationStatus("pending")
+		pending, err := store.DB.FindBridgeOperationStatus("pending")
 		if err != nil {
 			log.Printf("Error getting pending bridge operations by status: %v", err)
 		} else if pending == nil {
 			// log.Printf("No pending operations are present")
+		} else if pending.ForceReturn {
+			returnPending(pending)
+			// don't rush, it's decentralized nodes, etc.
+			sleep(ctx, 5*time.Second)
 		} else {
+			started := time.Now()
 			if pending.SourceChain > 0 {
 				// WBGL to BGL
 				log.Printf("Found pending WBGL to BGL bridge operation, %#v\n", pending)
+				prevStatus := "pending"
 
-				addrbookRecord, err := redis.GetAddressBookBySourceAddress(
+				addrbookRecord, err := store.DB.GetAddressBookBySourceAddress(
 					types.CHAINKEY_EVM,
 					strings.ToLower(pending.SourceAddress),
 				)
@@ -45,106 +53,128 @@ func Worker_processExecution() {
 					pending.Status = "failed"
 					msg := fmt.Sprintf("Error getting address book record: %s", err.Error())
 					log.Print(msg)
-					if pending.Message == "" {
-						pending.Message = msg
-					} else {
-						pending.Message += "; " + msg
-					}
+					pending.AppendMessage(msg)
 				} else if addrbookRecord == nil {
 					pending.Status = "failed"
 					msg := "Missing address book record"
 					log.Print(msg)
-					if pending.Message == "" {
-						pending.Message = msg
-					} else {
-						pending.Message += "; " + msg
+					pending.AppendMessage(msg)
+				} else if amount, err := pending.SourceAmount(); err != nil {
+					pending.Status = "failed"
+					msg := fmt.Sprintf("Invalid amount: %s", err.Error())
+					log.Print(msg)
+					pending.AppendMessage(msg)
+				} else if limits := fees.Limit(config.DirectionWBGLToBGL, pending.SourceChain); limits.Above(amount) && !pending.Approved {
+					pending.Status = "awaiting_approval"
+					pending.BindingID = addrbookRecord.ID
+					msg := fmt.Sprintf("Amount %s above maximum %s, awaiting approval", amount, limits.Max)
+					log.Print(msg)
+					pending.AppendMessage(msg)
+				} else if quote := fees.Compute(config.DirectionWBGLToBGL, pending.SourceChain, amount); limits.TooSmall(quote) != "" && config.Config.Limits.BelowMin == "hold" {
+					pending.Status = "held"
+					pending.BindingID = addrbookRecord.ID
+					msg := fmt.Sprintf("Amount %s %s, held", amount, limits.TooSmall(quote))
+					log.Print(msg)
+					pending.AppendMessage(msg)
+				} else if small := limits.TooSmall(quote); small != "" {
+					msg := fmt.Sprintf("Amount %s %s, returning", amount, small)
+					log.Print(msg)
+					pending.AppendMessage(msg)
+
+					pending.BindingID = addrbookRecord.ID
+					if !claimOperation(pending) {
+						continue
 					}
+					prevStatus = "executing"
+					prevStatus, err = returnFunds(pending, prevStatus)
+					if errors.Is(err, errNotStored) {
+						// claimed and never broadcast, left for operator
+						log.Printf("Bridge operation %s: %v, emergency exit", pending.ID, err)
+						Shutdown()
+						continue
+					}
+					sleep(ctx, 5*time.Second)
+				} else if amountSend, dust, dustRefund, err := splitDust(pending, quote.Receive); err != nil {
+					// BGL carries whole satoshis only
+					log.Printf("Error reading dust ledger: %v", err)
+					continue
+				} else if reason, err := payoutBlocked(pending, config.DirectionWBGLToBGL, pending.SourceChain, amountSend); err != nil {
+					log.Printf("Error checking payout caps: %v", err)
+					continue
+				} else if reason != "" {
+					pending.Status = "paused"
+					pending.BindingID = addrbookRecord.ID
+					msg := fmt.Sprintf("Payout paused: %s", reason)
+					log.Print(msg)
+					pending.AppendMessage(msg)
+					pauseRoute(config.DirectionWBGLToBGL, pending.SourceChain, reason)
 				} else {
-					amountBI, _ := big.NewInt(0).SetString(pending.Amount, 10)
-					divider, _ := big.NewInt(0).SetString("10000000000", 10)
-					amountBI = amountBI.Div(amountBI, divider)
-					amountFloat, _ := amountBI.Float64()
-					amountFloat = amountFloat / 100000000.0
-
-					// deduct bridge fee
-					amountFee := amountFloat * float64(config.Config.FeePercentage) / 100.0
-					amountFloat = amountFloat - amountFee
+					// bridge fee is deducted
+					amountFee := quote.Fee
 
-					// truncate to 8 decimal places
-					amountFloat = float64(int(amountFloat*100000000)) / 100000000
+					// claim operation before sending, so it is never sent twice
+					pending.BindingID = addrbookRecord.ID
+					if !claimOperation(pending) {
+						continue
+					}
+					prevStatus = "executing"
 
 					log.Printf(
-						"Sending BGL mainnet tx: %.8f (fee %.8f) to %s",
-						amountFloat,
+						"Sending BGL mainnet tx: %s (fee %s) to %s",
+						amountSend.BGLString(),
 						amountFee,
 						addrbookRecord.DestAddress,
 					)
 
-					tx, err := BGLRPC.GetClient().SendToAddress(addrbookRecord.DestAddress, amountFloat)
+					tx, err := BGLRPC.GetClient().SendToAddress(addrbookRecord.DestAddress, amountSend)
 					if err == nil {
+						bookDust(pending, dust, dustRefund)
+						recordOutflow(pending, config.DirectionWBGLToBGL, pending.SourceChain, amountSend)
 						pending.Status = "executing"
 						pending.DestAddress = addrbookRecord.DestAddress
 						pending.DestChain = 0
 						pending.DestTxHash = tx
 					} else {
 						msg := fmt.Sprintf(
-							"Error sending %.8f BGL to %v: %v, trying to return %s WBGL on %v",
-							amountFloat,
+							"Error sending %s BGL to %v: %v, trying to return %s WBGL on %v",
+							amountSend.BGLString(),
 							addrbookRecord.DestAddress,
 							err,
 							pending.Amount,
 							config.EVMChains[pending.SourceChain],
 						)
 						log.Print(msg)
-						if pending.Message == "" {
-							pending.Message = msg
-						} else {
-							pending.Message += "; " + msg
-						}
-
-						amountBIReturn, _ := big.NewInt(0).SetString(pending.Amount, 10)
-						tx, err := sendWBGL(pending.SourceChain, addrbookRecord.SourceAddress, amountBIReturn)
-
-						pending.DestChain = pending.SourceChain
-						pending.DestAddress = pending.SourceAddress
-						if err == nil {
-							log.Printf(
-								"Executed returning %s WBGL(%s) to %v, txid: %v",
-								amountBI.String(),
-								config.EVMChains[pending.DestChain].Name,
-								addrbookRecord.DestAddress,
-								tx.Hash().Hex(),
-							)
-							pending.Status = "returning"
-							pending.DestTxHash = tx.Hash().Hex()
-						} else {
-							log.Printf(
-								"Error returning %s WBGL to %v: %v",
-								amountBI.String(),
-								addrbookRecord.DestAddress,
-								err,
-							)
-							pending.Status = "returnfail"
+						pending.AppendMessage(msg)
+
+						prevStatus, err = returnFunds(pending, prevStatus)
+						if errors.Is(err, errNotStored) {
+							// claimed and never broadcast, left for operator
+							log.Printf("Bridge operation %s: %v, emergency exit", pending.ID, err)
+							Shutdown()
+							continue
 						}
 
 						// don't rush, it's decentralized nodes, etc.
-						time.Sleep(5 * time.Second)
+						sleep(ctx, 5*time.Second)
 					}
 				}
 
 				// update record
-				err = redis.ChangeBridgeOperationStatus(pending, "pending")
+				err = store.DB.ChangeBridgeOperationStatus(pending, prevStatus)
 				if err != nil {
 					// emergency exit
 					log.Printf("Error saving updated bridge operation: %v, emergency exit to avoid looping", err)
-					WorkerShutdown = true
+					Shutdown()
 				}
+				metrics.Executions.WithLabelValues(metrics.DirectionWBGLToBGL, pending.Status).Inc()
+				metrics.ExecutionDuration.WithLabelValues(metrics.DirectionWBGLToBGL).Observe(time.Since(started).Seconds())
 			} else {
 				// BGL to WBGL
 				log.Printf("Found pending BGL to WBGL bridge operation, %#v\n", pending)
 
-				sleep := false
-				addrbookRecord, err := redis.GetAddressBookBySourceAddress(
+				throttle := false
+				prevStatus := "pending"
+				addrbookRecord, err := store.DB.GetAddressBookBySourceAddress(
 					types.CHAINKEY_BGL,
 					strings.ToLower(pending.SourceAddress),
 				)
@@ -154,112 +184,123 @@ func Worker_processExecution() {
 				} else if addrbookRecord == nil {
 					log.Printf("Missing address book record")
 					pending.Status = "failed"
+				} else if amount, err := pending.SourceAmount(); err != nil {
+					log.Printf("Invalid amount: %s", err.Error())
+					pending.Status = "failed"
+				} else if limits := fees.Limit(config.DirectionBGLToWBGL, pending.DestChain); limits.Above(amount) && !pending.Approved {
+					log.Printf("Amount %s above maximum %s, awaiting approval", amount, limits.Max)
+					pending.Status = "awaiting_approval"
+					pending.BindingID = addrbookRecord.ID
+				} else if quote := fees.Compute(config.DirectionBGLToWBGL, pending.DestChain, amount); limits.TooSmall(quote) != "" && config.Config.Limits.BelowMin == "hold" {
+					log.Printf("Amount %s %s, held", amount, limits.TooSmall(quote))
+					pending.Status = "held"
+					pending.BindingID = addrbookRecord.ID
+				} else if small := limits.TooSmall(quote); small != "" && pending.DestTxNonce != nil {
+					// re-driven WBGL tx with pinned nonce may still be mined, returning BGL could pay twice
+					msg := fmt.Sprintf("Amount %s %s, WBGL tx %s may still be mined, left for operator", amount, small, pending.DestTxHash)
+					log.Print(msg)
+					pending.AppendMessage(msg)
+					pending.Status = "failed"
+					pending.BindingID = addrbookRecord.ID
+				} else if small != "" {
+					log.Printf("Amount %s %s, returning", amount, small)
+					pending.BindingID = addrbookRecord.ID
+					if !claimOperation(pending) {
+						continue
+					}
+					prevStatus = "executing"
+					// BGL return is stored by the update below
+					prevStatus, _ = returnFunds(pending, prevStatus)
+					throttle = true
+				} else if reason, err := payoutBlocked(pending, config.DirectionBGLToWBGL, pending.DestChain, quote.Receive); err != nil {
+					log.Printf("Error checking payout caps: %v", err)
+					continue
+				} else if reason != "" {
+					log.Printf("Payout paused: %s", reason)
+					pending.Status = "paused"
+					pending.BindingID = addrbookRecord.ID
+					pauseRoute(config.DirectionBGLToWBGL, pending.DestChain, reason)
 				} else {
-					amountBF, _ := big.NewFloat(0).SetString(pending.Amount)
-					multiplier, _ := big.NewFloat(0).SetString("1000000000000000000")
-					amountBF = amountBF.Mul(amountBF, multiplier)
-
-					amountBI := big.NewInt(0)
-					amountBI, _ = amountBF.Int(amountBI)
-
 					// deduct bridge fee
-					amountFee := big.NewInt(0).Set(amountBI)
-					amountFee = amountFee.Mul(amountFee, big.NewInt(int64(config.Config.FeePercentage)))
-					amountFee = amountFee.Div(amountFee, big.NewInt(100))
-					amountBI = amountBI.Sub(amountBI, amountFee)
+					amountFee := quote.Fee
+					amountSend := quote.Receive
 
 					// update record immediately to prevent looped sending if some error
-					pending.Status = "executing"
-					err = redis.ChangeBridgeOperationStatus(pending, "pending")
-					if err != nil {
-						// emergency exit
-						log.Printf("Error saving updated bridge operation: %v, emergency exit to avoid looping", err)
-						WorkerShutdown = true
+					pending.BindingID = addrbookRecord.ID
+					if !claimOperation(pending) {
+						continue
 					}
+					prevStatus = "executing"
 
 					log.Printf(
 						"Sending WBGL(%s) tx: %s (fee %s) to %s",
 						config.EVMChains[pending.DestChain].Name,
-						amountBI.String(),
-						amountFee.String(),
+						amountSend,
+						amountFee,
 						addrbookRecord.DestAddress,
 					)
-					tx, err := sendWBGL(pending.DestChain, addrbookRecord.DestAddress, amountBI)
-					sleep = true
+					tx, err := sendWBGL(pending, "executing", addrbookRecord.DestAddress, amountSend)
+					throttle = true
+					if errors.Is(err, errNotStored) {
+						// claimed and never broadcast, left for operator
+						log.Printf("Bridge operation %s: %v, emergency exit", pending.ID, err)
+						Shutdown()
+						continue
+					}
 
 					if err == nil {
+						recordOutflow(pending, config.DirectionBGLToWBGL, pending.DestChain, amountSend)
 						log.Printf(
 							"Executed sending %s WBGL(%s) to %v, txid: %v",
-							amountBI.String(),
+							amountSend,
 							config.EVMChains[pending.DestChain].Name,
 							addrbookRecord.DestAddress,
 							tx.Hash().Hex(),
 						)
-						pending.DestTxHash = tx.Hash().Hex()
+
+					} else if pending.DestTxNonce != nil {
+						// re-driven WBGL tx with pinned nonce may still be mined, returning BGL could pay twice
+						msg := fmt.Sprintf(
+							"Error sending %s WBGL to %v: %v, tx %s may still be mined, left for operator",
+							amountSend,
+							addrbookRecord.DestAddress,
+							err,
+							pending.DestTxHash,
+						)
+						log.Print(msg)
+						pending.AppendMessage(msg)
+						pending.Status = "failed"
 
 					} else {
 
 						msg := fmt.Sprintf(
 							"Error sending %s WBGL to %v: %v, trying to return %s BGL",
-							amountBI.String(),
+							amountSend,
 							addrbookRecord.DestAddress,
 							err,
 							pending.Amount,
 						)
 						log.Print(msg)
-						if pending.Message == "" {
-							pending.Message = msg
-						} else {
-							pending.Message += "; " + msg
-						}
+						pending.AppendMessage(msg)
 
-						sourceSenderAddress, err := BGLRPC.GetClient().GetFromAddressForTransaction(pending.SourceTxHash)
-						if err != nil {
-							log.Printf(
-								"Error getting sender address for txid %s BGL to %v: %v",
-								pending.Amount,
-								pending.SourceTxHash,
-								err,
-							)
-							pending.Status = "returnfail"
-						} else {
-							pending.DestChain = pending.SourceChain
-							pending.DestAddress = sourceSenderAddress
-							amountFloatReturn, _ := strconv.ParseFloat(pending.Amount, 64)
-							tx, err := BGLRPC.GetClient().SendToAddress(sourceSenderAddress, amountFloatReturn)
-							if err == nil {
-								log.Printf(
-									"Executed returning %.8f BGL to %v, txid: %v",
-									amountFloatReturn,
-									sourceSenderAddress,
-									tx,
-								)
-								pending.Status = "returning"
-								pending.DestTxHash = tx
-							} else {
-								log.Printf(
-									"Error returning %.8f BGL to %v: %v",
-									amountFloatReturn,
-									sourceSenderAddress,
-									err,
-								)
-								pending.Status = "returnfail"
-							}
-						}
+						// BGL return is stored by the update below
+						prevStatus, _ = returnFunds(pending, prevStatus)
 					}
 				}
 
 				// update record
-				err = redis.ChangeBridgeOperationStatus(pending, "pending")
+				err = store.DB.ChangeBridgeOperationStatus(pending, prevStatus)
 				if err != nil {
 					// emergency exit
 					log.Printf("Error saving updated bridge operation: %v, emergency exit to avoid looping", err)
-					WorkerShutdown = true
+					Shutdown()
 				}
+				metrics.Executions.WithLabelValues(metrics.DirectionBGLToWBGL, pending.Status).Inc()
+				metrics.ExecutionDuration.WithLabelValues(metrics.DirectionBGLToWBGL).Observe(time.Since(started).Seconds())
 
-				if sleep {
+				if throttle {
 					// don't rush, it's decentralized nodes, etc.
-					time.Sleep(5 * time.Second)
+					sleep(ctx, 5*time.Second)
 				}
 			}
 		}
@@ -268,30 +309,61 @@ func Worker_processExecution() {
 	}
 }
 
-func sendWBGL(chainId int, address string, amount *big.Int) (*ethtypes.Transaction, error) {
+// records sent EVM tx, earlier tx with the same (pinned) nonce may still get mined instead
+func setDestEVMTx(op *types.BridgeOperation, tx *ethtypes.Transaction) {
+	if op.DestTxNonce != nil && *op.DestTxNonce == tx.Nonce() && op.DestTxHash != "" {
+		op.ReplacedTxHashes = append(op.ReplacedTxHashes, op.DestTxHash)
+	}
+	op.DestTxHash = tx.Hash().Hex()
+	nonce := tx.Nonce()
+	op.DestTxNonce = &nonce
+	op.DestGasLimit = tx.Gas()
+	raw, _ := tx.MarshalBinary()
+	op.DestRawTx = hexutil.Encode(raw)
+}
+
+// signed tx could not be recorded on operation, so it was not broadcast
+var errNotStored = errors.New("signed tx not stored")
+
+// signs transfer on op.DestChain and records it on op in the given status before broadcast,
+// once recorded the tx is never signed again with another nonce, lost broadcast is repeated by Worker_trackEVM;
+// nonce is pinned (op.DestTxNonce) when re-driving a never mined tx, so the original can never be mined as well
+func sendWBGL(op *types.BridgeOperation, status string, address string, amount types.Amount) (*ethtypes.Transaction, error) {
 	var tx *ethtypes.Transaction
+	chainId := op.DestChain
+	fixedNonce := op.DestTxNonce
+
+	nonce := uint64(0)
+	if fixedNonce != nil {
+		nonce = *fixedNonce
+	} else {
+		var err error
+		nonce, err = reserveNonce(chainId)
+		if err != nil {
+			return nil, fmt.Errorf("error reserving nonce for wallet: %s", err)
+		}
+	}
+	// nonce of a recorded tx is never released
+	stored := false
+	defer func() {
+		if fixedNonce == nil && !stored {
+			releaseNonce(chainId, nonce)
+		}
+	}()
 
 	var reterr error
 	for i := 0; i < config.EVM_RETRIES; i++ {
-		nonce, err := EVMRPC.WithClient(
-			chainId, func(client *ethclient.Client) (uint64, error) {
-				return client.PendingNonceAt(context.Background(), common.HexToAddress(config.Config.EVM.PublicAddress))
-			},
-		)
+		txFees, err := suggestFees(context.Background(), chainId)
 		if err != nil {
-			reterr = fmt.Errorf("error getting nonce for wallet: %s", err)
-			log.Print(err.Error())
+			reterr = fmt.Errorf("error getting suggested gas fees: %s", err)
+			log.Print(reterr.Error())
 			continue
 		}
 
-		gasPrice, err := EVMRPC.WithClient(
-			chainId, func(client *ethclient.Client) (*big.Int, error) {
-				return client.SuggestGasPrice(context.Background())
-			},
-		)
+		gasLimit, err := estimateTransferGas(context.Background(), chainId, address, amount.Wei())
 		if err != nil {
-			reterr = fmt.Errorf("error getting suggested gas price: %s", err)
-			log.Print(err.Error())
+			reterr = fmt.Errorf("error estimating transfer gas: %s", err)
+			log.Print(reterr.Error())
 			continue
 		}
 
@@ -306,13 +378,15 @@ func sendWBGL(chainId int, address string, amount *big.Int) (*ethtypes.Transacti
 
 		auth.Nonce = big.NewInt(int64(nonce))
 		auth.Value = big.NewInt(0)
-		auth.GasLimit = uint64(200000)
-		if chainId == 1 {
-			auth.GasPrice = gasPrice
+		auth.GasLimit = gasLimit
+		if txFees.dynamic() {
+			auth.GasTipCap = txFees.tipCap
+			auth.GasFeeCap = txFees.feeCap
 		} else {
-			auth.GasPrice = gasPrice.Mul(gasPrice, big.NewInt(2))
+			auth.GasPrice = txFees.gasPrice
 		}
 
+		auth.NoSend = true
 		tx, err = EVMRPC.WithClient(
 			chainId, func(client *ethclient.Client) (*ethtypes.Transaction, error) {
 				WBGL, err := ierc20.NewIerc20(common.HexToAddress(config.EVMChains[chainId].ContractAddress), client)
@@ -320,18 +394,51 @@ func sendWBGL(chainId int, address string, amount *big.Int) (*ethtypes.Transacti
 					log.Println(fmt.Sprintf("Error creating contract instance: %s", err))
 					return nil, err
 				}
-				return WBGL.Transfer(auth, common.HexToAddress(address), amount)
+				return WBGL.Transfer(auth, common.HexToAddress(address), amount.Wei())
 			},
 		)
 
 		if err != nil {
-			reterr = fmt.Errorf("error calling transfer method: %s", err)
-			log.Print(err.Error())
+			reterr = fmt.Errorf("error signing transfer: %s", err)
+			log.Print(reterr.Error())
 			continue
 		}
+		break
+	}
+	if tx == nil {
+		return nil, reterr
+	}
 
-		return tx, nil
+	// write-ahead, after a crash stored tx is rebroadcast instead of sending funds again
+	signed := *op
+	signed.Status = status
+	setDestEVMTx(&signed, tx)
+	err := store.DB.ChangeBridgeOperationStatus(&signed, op.Status)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errNotStored, err)
+	}
+	*op = signed
+	stored = true
+
+	log.Printf(
+		"WBGL(%s) tx %s: gas limit %d, max fee %s wei",
+		config.EVMChains[chainId].Name,
+		tx.Hash().Hex(),
+		tx.Gas(),
+		new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasFeeCap()),
+	)
+	err = broadcastEVMTx(context.Background(), chainId, tx)
+	if err != nil {
+		log.Printf("Error broadcasting tx %s, will be rebroadcast: %v", tx.Hash().Hex(), err)
 	}
+	return tx, nil
+}
 
-	return tx, reterr
+func broadcastEVMTx(ctx context.Context, chainId int, tx *ethtypes.Transaction) error {
+	_, err := EVMRPC.WithClient(
+		chainId, func(client *ethclient.Client) (bool, error) {
+			return true, client.SendTransaction(ctx, tx)
+		},
+	)
+	return err
 }
@@ -0,0 +1,145 @@
+package workers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"gobglbridge/BGLRPC"
+	"gobglbridge/config"
+	"gobglbridge/store"
+	"gobglbridge/types"
+)
+
+// moves pending op to executing, false if another executor took it or it could not be stored
+func claimOperation(op *types.BridgeOperation) bool {
+	op.Status = "executing"
+	err := store.DB.ChangeBridgeOperationStatus(op, "pending")
+	if errors.Is(err, types.ErrStatusConflict) {
+		// another executor has already picked it up, never send twice
+		log.Printf("Bridge operation %s is not pending anymore, skipping: %v", op.ID, err)
+		return false
+	} else if err != nil {
+		// emergency exit
+		log.Printf("Error saving updated bridge operation: %v, emergency exit to avoid looping", err)
+		Shutdown()
+		return false
+	}
+	return true
+}
+
+// returns funds of pending op on operator request, see admin API
+func returnPending(op *types.BridgeOperation) {
+	log.Printf("Returning bridge operation %s on operator request", op.ID)
+	if !claimOperation(op) {
+		return
+	}
+
+	stored, err := returnFunds(op, "executing")
+	if errors.Is(err, errNotStored) {
+		// claimed and never broadcast, left for operator
+		log.Printf("Bridge operation %s: %v, emergency exit", op.ID, err)
+		Shutdown()
+		return
+	}
+	err = store.DB.ChangeBridgeOperationStatus(op, stored)
+	if err != nil {
+		// emergency exit
+		log.Printf("Error saving updated bridge operation: %v, emergency exit to avoid looping", err)
+		Shutdown()
+	}
+}
+
+// sends source amount back to the sender of claimed (not pending) op, leaving it "returning" or "returnfail",
+// or "paused" until route caps have room for the return;
+// stored is the status op is stored with and the updated one is returned (WBGL return tx is recorded before broadcast),
+// errNotStored if signed return tx could not be recorded
+func returnFunds(op *types.BridgeOperation, stored string) (string, error) {
+	amount, err := op.SourceAmount()
+	if err != nil {
+		log.Printf("Invalid amount of bridge operation %s: %v", op.ID, err)
+		op.Status = "returnfail"
+		return stored, nil
+	}
+
+	// returns are payouts too, capped like forward ones
+	direction, chainId := returnRoute(op)
+	reason, err := payoutBlocked(op, direction, chainId, amount)
+	if err != nil {
+		reason = fmt.Sprintf("error checking caps: %v", err)
+	}
+	if reason != "" {
+		// resumed by Worker_caps, executor returns it then
+		op.ForceReturn = true
+		op.Status = "paused"
+		msg := fmt.Sprintf("Return paused: %s", reason)
+		log.Print(msg)
+		op.AppendMessage(msg)
+		pauseRoute(direction, chainId, reason)
+		return stored, nil
+	}
+
+	if op.SourceChain > 0 {
+		op.DestChain = op.SourceChain
+		op.DestAddress = op.SourceAddress
+		tx, err := sendWBGL(op, "returning", op.SourceAddress, amount)
+		if errors.Is(err, errNotStored) {
+			return stored, err
+		}
+		if err != nil {
+			log.Printf(
+				"Error returning %s WBGL to %v: %v",
+				amount,
+				op.SourceAddress,
+				err,
+			)
+			op.Status = "returnfail"
+			return stored, nil
+		}
+		recordOutflow(op, direction, chainId, amount)
+		log.Printf(
+			"Executed returning %s WBGL(%s) to %v, txid: %v",
+			amount,
+			config.EVMChains[op.DestChain].Name,
+			op.SourceAddress,
+			tx.Hash().Hex(),
+		)
+		return op.Status, nil
+	}
+
+	sourceSenderAddress, err := BGLRPC.GetClient().GetFromAddressForTransaction(op.SourceTxHash)
+	if err != nil {
+		log.Printf(
+			"Error getting sender address for txid %s BGL to %v: %v",
+			op.Amount,
+			op.SourceTxHash,
+			err,
+		)
+		op.Status = "returnfail"
+		return stored, nil
+	}
+
+	op.DestChain = op.SourceChain
+	op.DestAddress = sourceSenderAddress
+	tx, err := BGLRPC.GetClient().SendToAddress(sourceSenderAddress, amount)
+	if err != nil {
+		log.Printf(
+			"Error returning %s BGL to %v: %v",
+			amount.BGLString(),
+			sourceSenderAddress,
+			err,
+		)
+		op.Status = "returnfail"
+		return stored, nil
+	}
+	recordOutflow(op, direction, chainId, amount)
+	log.Printf(
+		"Executed returning %s BGL to %v, txid: %v",
+		amount.BGLString(),
+		sourceSenderAddress,
+		tx,
+	)
+	op.Status = "returning"
+	op.DestTxHash = tx
+	return stored, nil
+}
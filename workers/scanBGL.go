@@ -1,10 +1,11 @@
 package workers
 
 import (
-	"fmt"
+	"context"
 	"gobglbridge/BGLRPC"
 	"gobglbridge/config"
-	"gobglbridge/redis"
+	"gobglbridge/metrics"
+	"gobglbridge/store"
 	"gobglbridge/types"
 	"log"
 	"time"
@@ -12,12 +13,11 @@ import (
 	"github.com/google/uuid"
 )
 
-func Worker_scanBGL() {
-	for !WorkerShutdown {
-		// BGL is Bitcoin-derived and we don't need high frequency iterating
-		time.Sleep(30 * time.Second)
+func Worker_scanBGL(ctx context.Context) {
+	// BGL is Bitcoin-derived and we don't need high frequency iterating
+	for sleep(ctx, 30*time.Second) {
 
-		scannedBlockHash, err := redis.GetBGLScannedBlock()
+		scannedBlockHash, err := store.DB.GetBGLScannedBlock()
 		if err != nil {
 			log.Printf("Error getting last scanned BGL block hash: %s", err.Error())
 			continue
@@ -31,7 +31,7 @@ func Worker_scanBGL() {
 
 		for _, tx := range transactions {
 			if tx.Confirmations >= int64(config.Config.BGL.Confirmations) && tx.Category == "receive" {
-				addrbook, err := redis.GetAddressBookBySourceAddress(types.CHAINKEY_BGL, tx.Address)
+				addrbook, err := store.DB.GetAddressBookBySourceAddress(types.CHAINKEY_BGL, tx.Address)
 				if err != nil {
 					log.Printf("Error checking address book record: %s", err.Error())
 					continue
@@ -42,21 +42,22 @@ func Worker_scanBGL() {
 					log.Printf("BGL transfer %s: from: %s, to: %v, amount: %v. Saving incoming bridge tx.", tx.TxID, "-", tx.Address, tx.Amount)
 
 					// never add record if a record present with same source tx hash, otherwise could be double send
-					existingOp, err := redis.FindBridgeOperationSourceTxHash(tx.TxID)
+					existingOp, err := store.DB.FindBridgeOperationSourceTxHash(tx.TxID)
 
 					if existingOp == nil && err == nil {
 						// store new bridge tx to redis
-						err = redis.UpsertBridgeOperation(&types.BridgeOperation{
+						err = store.DB.UpsertBridgeOperation(&types.BridgeOperation{
 							ID:            uuid.New().String(),
 							Status:        "pending",
 							SourceChain:   0,
 							DestChain:     addrbook.DestChain, // only support now bridging to/from BGL mainnet
 							TsFound:       time.Now().Unix(),
-							Amount:        fmt.Sprintf("%.8f", tx.Amount),
+							Amount:        types.AmountFromBGLFloat(tx.Amount).BGLString(),
 							SourceAddress: tx.Address,
 							DestAddress:   addrbook.DestAddress,
 							SourceTxHash:  tx.TxID,
 							DestTxHash:    "",
+							BindingID:     addrbook.ID,
 						})
 
 						if err != nil {
@@ -72,13 +73,13 @@ func Worker_scanBGL() {
 				} else {
 					log.Printf("ERROR: missing address book record for %d:%s", 0, tx.Address)
 
-					err = redis.UpsertBridgeOperation(&types.BridgeOperation{
+					err = store.DB.UpsertBridgeOperation(&types.BridgeOperation{
 						ID:            uuid.New().String(),
 						Status:        "failed",
 						SourceChain:   0,
 						DestChain:     -1, // unknown
 						TsFound:       time.Now().Unix(),
-						Amount:        fmt.Sprintf("%.8f", tx.Amount),
+						Amount:        types.AmountFromBGLFloat(tx.Amount).BGLString(),
 						SourceAddress: tx.Address,
 						DestAddress:   "",
 						SourceTxHash:  tx.TxID,
@@ -97,6 +98,14 @@ func Worker_scanBGL() {
 			}
 		}
 
-		redis.SetBGLScannedBlock(lastblock)
+		// checkpoint reset by operator meanwhile is kept, scan starts from there next round
+		swapped, err := store.DB.SwapBGLScannedBlock(scannedBlockHash, lastblock)
+		if err != nil {
+			log.Printf("Error saving BGL scan checkpoint: %s", err.Error())
+		} else if !swapped {
+			log.Printf("BGL scan checkpoint was changed meanwhile, rescanning from it")
+		} else {
+			metrics.BGLScanned()
+		}
 	}
 }
@@ -4,13 +4,15 @@ import (
 	"context"
 	"log"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"gobglbridge/EVMRPC"
 	"gobglbridge/config"
-	"gobglbridge/redis"
+	"gobglbridge/metrics"
+	"gobglbridge/store"
 	"gobglbridge/types"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -26,12 +28,11 @@ type reqData struct {
 	ToBlock   string `json:"toBlock,omitempty"`
 }
 
-func Worker_scanEVM(chainId int) {
-	for !WorkerShutdown {
-		// latency of <30 sec should be ok for EVM chains (even if Arb is faster)
-		time.Sleep(10 * time.Second)
+func Worker_scanEVM(ctx context.Context, chainId int) {
+	// latency of <30 sec should be ok for EVM chains (even if Arb is faster)
+	for sleep(ctx, 10*time.Second) {
 
-		scannedBlockNum, err := redis.GetEVMScannedBlock(chainId)
+		scannedBlockNum, err := store.DB.GetEVMScannedBlock(chainId)
 		if err != nil {
 			log.Printf("Error getting last scanned EVM block hash: %s", err.Error())
 			continue
@@ -45,7 +46,7 @@ func Worker_scanEVM(chainId int) {
 
 		latestBlock, err := EVMRPC.WithClient(
 			chainId, func(client *ethclient.Client) (uint64, error) {
-				return client.BlockNumber(context.Background())
+				return client.BlockNumber(ctx)
 			},
 		)
 		if err != nil {
@@ -54,6 +55,16 @@ func Worker_scanEVM(chainId int) {
 		}
 		// fmt.Printf("Latest block on %s is: %d, last scanned is: %d\n", config.EVMChains[chainId].Name, latestBlock, scannedBlockNum)
 
+		if scannedBlockNum != -1 {
+			metrics.EVMScanLag.WithLabelValues(metrics.ChainName(chainId)).Set(float64(int(latestBlock) - scannedBlockNum))
+		}
+
+		// only scan blocks having enough confirmations
+		if latestBlock < uint64(config.EVMChains[chainId].MinConfirmations) {
+			continue
+		}
+		latestBlock -= uint64(config.EVMChains[chainId].MinConfirmations)
+
 		if scannedBlockNum == -1 {
 			scannedBlockNum = int(latestBlock) - config.EVMChains[chainId].SafetyWindow
 		} else {
@@ -79,7 +90,7 @@ func Worker_scanEVM(chainId int) {
 			logs, err := EVMRPC.WithClient(
 				chainId, func(client *ethclient.Client) ([]ethtypes.Log, error) {
 					return client.FilterLogs(
-						context.Background(), ethereum.FilterQuery{
+						ctx, ethereum.FilterQuery{
 							FromBlock: big.NewInt(fromBlock),
 							ToBlock:   big.NewInt(toBlock),
 							Addresses: []common.Address{common.HexToAddress(config.EVMChains[chainId].ContractAddress)},
@@ -93,6 +104,8 @@ func Worker_scanEVM(chainId int) {
 				break
 			}
 
+			// store errors leave the batch unprocessed, it's scanned again next round
+			processed := true
 			for _, l := range logs {
 				txHash := l.TxHash.String()
 				sender := common.HexToAddress(l.Topics[1].String())
@@ -103,7 +116,7 @@ func Worker_scanEVM(chainId int) {
 				if recipient.Hex() == common.HexToAddress(config.Config.EVM.PublicAddress).Hex() {
 
 					// never add record if a record present with same source tx hash, otherwise could be double send
-					existingOp, err := redis.FindBridgeOperationSourceTxHash(txHash)
+					existingOp, err := store.DB.FindBridgeOperationSourceTxHash(txHash)
 
 					if existingOp == nil && err == nil {
 						log.Printf(
@@ -114,25 +127,36 @@ func Worker_scanEVM(chainId int) {
 							amount,
 						)
 
+						// link to the binding if present, missing one is handled by execution worker
+						bindingID := ""
+						addrbook, err := store.DB.GetAddressBookBySourceAddress(types.CHAINKEY_EVM, sender.Hex())
+						if err != nil {
+							log.Printf("Error checking address book record: %s", err.Error())
+						} else if addrbook != nil {
+							bindingID = addrbook.ID
+						}
+
 						// store new bridge tx to redis
-						err = redis.UpsertBridgeOperation(
+						err = store.DB.UpsertBridgeOperation(
 							&types.BridgeOperation{
 								ID:            uuid.New().String(),
 								Status:        "pending",
 								SourceChain:   chainId,
 								DestChain:     0, // only support bridging to/from BGL mainnet
 								TsFound:       time.Now().Unix(),
-								Amount:        amount.String(),
+								Amount:        types.AmountFromWei(amount).WeiString(),
 								SourceAddress: sender.Hex(),
 								DestAddress:   "", // filled by execution worker
 								SourceTxHash:  txHash,
 								DestTxHash:    "",
+								BindingID:     bindingID,
 							},
 						)
 
 						if err != nil {
 							// don't consider this block as processed
 							log.Printf("Cannot create pending bridge operation, Redis error: %s", err.Error())
+							processed = false
 							break
 						}
 					} else if existingOp != nil {
@@ -142,12 +166,14 @@ func Worker_scanEVM(chainId int) {
 						)
 					} else {
 						log.Printf("Error searching Redis: %s", err.Error())
+						processed = false
+						break
 					}
 				} else if sender.Hex() == common.HexToAddress(config.Config.EVM.PublicAddress).Hex() {
 
 					// record should be present with same source tx hash or destination tx hash, otherwise this orphaned (manual?) transfer from bridge wallet
 					// in destination tx hashes when processing in progress
-					existingOp, err := redis.FindBridgeOperationDestinationTxHash(txHash)
+					existingOp, err := store.DB.FindBridgeOperationDestinationTxHash(txHash)
 
 					if existingOp == nil && err == nil {
 						log.Printf(
@@ -156,9 +182,11 @@ func Worker_scanEVM(chainId int) {
 						)
 					} else if err != nil {
 						log.Printf("Error searching Redis: %s", err.Error())
+						processed = false
+						break
 					} else {
 
-						// TODO: take into consideration failed (reverted) txs
+						// reverted txs emit no logs, those are handled by Worker_trackEVM
 
 						log.Printf(
 							"WBGL transfer %s: from: %s, to: %v, amount: %v. Finalizing outgoing/returned bridge tx.",
@@ -175,31 +203,64 @@ func Worker_scanEVM(chainId int) {
 							existingOp.Status = "returnsuccess"
 						} else if existingOp.Status == "success" || existingOp.Status == "returnsuccess" {
 							// do nothing, tx processed, all ok
-							break
+							continue
 						} else {
 							log.Printf(
 								"Error: found existing operation with destination hash %s with unexpected status %s",
 								txHash,
 								existingOp.Status,
 							)
-							break
+							continue
+						}
+						// replaced tx could be mined instead of the last one sent
+						for i, replaced := range existingOp.ReplacedTxHashes {
+							if strings.EqualFold(replaced, txHash) {
+								existingOp.ReplacedTxHashes[i] = existingOp.DestTxHash
+								existingOp.DestTxHash = txHash
+								break
+							}
+						}
+						fee, err := minedEVMTxFee(ctx, chainId, txHash)
+						if err != nil {
+							log.Printf("Error getting fee of tx %s: %v", txHash, err)
+						} else {
+							existingOp.DestTxFee = fee.String()
 						}
 						// update info about operation in redis
-						err = redis.ChangeBridgeOperationStatus(existingOp, prevStatus)
+						err = store.DB.ChangeBridgeOperationStatus(existingOp, prevStatus)
 
 						if err != nil {
-							// don't consider this block as processed
+							// don't consider this block as processed, conflicting change is picked up on rescan
 							log.Printf("Cannot update bridge operation status, Redis error: %s", err.Error())
+							processed = false
 							break
 						}
 					}
 				}
 			}
+			if !processed {
+				break
+			}
 
-			lastScannedBlock = int(toBlock)
 			time.Sleep(50 * time.Millisecond)
 
-			redis.SetEVMScannedBlock(chainId, lastScannedBlock)
+			// checkpoint reset by operator meanwhile is kept, scan starts from there next round
+			swapped, err := store.DB.SwapEVMScannedBlock(chainId, lastScannedBlock, int(toBlock))
+			if err != nil {
+				log.Printf("Error saving %s scan checkpoint: %s", config.EVMChains[chainId].Name, err.Error())
+				break
+			}
+			if !swapped {
+				log.Printf("%s scan checkpoint was changed meanwhile, rescanning from it", config.EVMChains[chainId].Name)
+				break
+			}
+			lastScannedBlock = int(toBlock)
+			metrics.EVMScanLag.WithLabelValues(metrics.ChainName(chainId)).Set(float64(int(latestBlock) - lastScannedBlock))
+
+			// resume from checkpoint after restart
+			if ctx.Err() != nil {
+				break
+			}
 		}
 	}
 }
@@ -0,0 +1,167 @@
+package workers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// static web app file, kept in memory with precompressed variants
+type staticFile struct {
+	name        string
+	modTime     time.Time
+	contentType string
+	etag        string
+	raw         []byte
+	gzip        []byte // nil when compression does not pay off
+	brotli      []byte
+}
+
+// serves the web app, unknown paths fall back to index.html (no directory listing)
+type staticApp struct {
+	files map[string]*staticFile
+	index *staticFile
+}
+
+func newStaticApp(fsys fs.FS) (*staticApp, error) {
+	app := &staticApp{files: map[string]*staticFile{}}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		raw, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(raw)
+		f := &staticFile{
+			name:        name,
+			modTime:     info.ModTime(),
+			contentType: mime.TypeByExtension(filepath.Ext(name)),
+			etag:        hex.EncodeToString(sum[:8]),
+			raw:         raw,
+		}
+		if f.contentType == "" {
+			f.contentType = http.DetectContentType(raw)
+		}
+		if compressible(f.contentType) {
+			f.gzip = compressGzip(raw)
+			f.brotli = compressBrotli(raw)
+		}
+
+		app.files[name] = f
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	app.index = app.files["index.html"]
+	return app, nil
+}
+
+func compressible(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "javascript") ||
+		strings.Contains(contentType, "json") ||
+		strings.Contains(contentType, "xml") ||
+		strings.Contains(contentType, "wasm")
+}
+
+func compressGzip(raw []byte) []byte {
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	zw.Write(raw)
+	zw.Close()
+	if buf.Len() >= len(raw) {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+func compressBrotli(raw []byte) []byte {
+	var buf bytes.Buffer
+	bw := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+	bw.Write(raw)
+	bw.Close()
+	if buf.Len() >= len(raw) {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// encoding is listed in Accept-Encoding and not refused with q=0
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+		_, q, found := strings.Cut(strings.ReplaceAll(params, " ", ""), "q=")
+		if !found {
+			return true
+		}
+		weight, err := strconv.ParseFloat(q, 64)
+		return err == nil && weight > 0
+	}
+	return false
+}
+
+func (a *staticApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+strings.Replace(r.URL.Path, "\\", "/", -1)), "/")
+
+	f, ok := a.files[name]
+	if !ok {
+		f = a.index
+	}
+	if f == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// index is revalidated every time, other assets can be cached for a while
+	if f == a.index {
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+	}
+	w.Header().Set("Content-Type", f.contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	body := f.raw
+	etag := f.etag
+	if f.gzip != nil || f.brotli != nil {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	if f.brotli != nil && acceptsEncoding(r, "br") {
+		body = f.brotli
+		etag += "-br"
+		w.Header().Set("Content-Encoding", "br")
+	} else if f.gzip != nil && acceptsEncoding(r, "gzip") {
+		body = f.gzip
+		etag += "-gz"
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+	w.Header().Set("ETag", `"`+etag+`"`)
+
+	// handles If-None-Match, ranges and HEAD
+	http.ServeContent(w, r, f.name, f.modTime, bytes.NewReader(body))
+}
@@ -1,4 +1,62 @@
 package workers
 
-// global flag to shutdown worker threads
-var WorkerShutdown = false
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// cancels root context of all workers
+	shutdown context.CancelFunc
+	running  sync.WaitGroup
+)
+
+// root context for worker threads, cancelled on SIGINT/SIGTERM or by Shutdown
+func Context() context.Context {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	ctx, cancel := context.WithCancel(ctx)
+	shutdown = func() {
+		cancel()
+		stop()
+	}
+	return ctx
+}
+
+// starts worker thread, Wait returns only after every worker returned
+func Run(ctx context.Context, worker func(ctx context.Context)) {
+	running.Add(1)
+	go func() {
+		defer running.Done()
+		worker(ctx)
+	}()
+}
+
+// signals all workers to finish current iteration and exit
+func Shutdown() {
+	if shutdown != nil {
+		shutdown()
+	}
+}
+
+func Wait() {
+	running.Wait()
+	log.Print("All workers stopped")
+}
+
+// returns false if context was cancelled before d elapsed
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,85 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gobglbridge/BGLRPC"
+	"gobglbridge/config"
+	"gobglbridge/store"
+	"gobglbridge/types"
+)
+
+// BGL scanner only sees incoming txs, outgoing BGL sends (WBGL to BGL and BGL returns)
+// are followed here until they are deep enough or can never confirm
+func Worker_trackBGL(ctx context.Context) {
+	for sleep(ctx, time.Minute) {
+		for _, status := range []string{"executing", "returning"} {
+			ops, err := store.DB.FindAllBridgeOperationsByStatus(status)
+			if err != nil {
+				log.Printf("Error getting %s bridge operations: %v", status, err)
+				continue
+			}
+
+			for _, op := range ops {
+				if op.DestChain != 0 || op.DestTxHash == "" {
+					continue
+				}
+				trackBGLTx(op)
+			}
+		}
+	}
+}
+
+func trackBGLTx(op *types.BridgeOperation) {
+	tx, err := BGLRPC.GetClient().GetWalletTransaction(op.DestTxHash)
+	if errors.Is(err, BGLRPC.ErrUnknownTransaction) {
+		// e.g. wallet restored from backup, cannot tell whether it was sent
+		log.Printf("Bridge operation %s: BGL tx %s is unknown to wallet", op.ID, op.DestTxHash)
+		return
+	} else if err != nil {
+		log.Printf("Error getting BGL tx %s: %v", op.DestTxHash, err)
+		return
+	}
+
+	reason := ""
+	switch {
+	case tx.Confirmations >= max(int64(config.Config.BGL.Confirmations), 1):
+		prevStatus := op.Status
+		if op.Status == "returning" {
+			op.Status = "returnsuccess"
+		} else {
+			op.Status = "success"
+		}
+		log.Printf("Bridge operation %s: BGL tx %s has %d confirmations, %s", op.ID, op.DestTxHash, tx.Confirmations, op.Status)
+		err = store.DB.ChangeBridgeOperationStatus(op, prevStatus)
+		if err != nil && !errors.Is(err, types.ErrStatusConflict) {
+			log.Printf("Cannot update bridge operation status: %v", err)
+		}
+		return
+	case tx.Confirmations < 0:
+		reason = fmt.Sprintf("BGL tx %s conflicted with %v", op.DestTxHash, tx.WalletConflicts)
+	case tx.Abandoned():
+		reason = fmt.Sprintf("BGL tx %s abandoned", op.DestTxHash)
+	default:
+		// in mempool or not deep enough yet
+		return
+	}
+
+	if op.Status == "returning" {
+		// original EVM destination was replaced by the return, left for operator
+		prevStatus := op.Status
+		op.Status = "returnfail"
+		log.Printf("Bridge operation %s: %s", op.ID, reason)
+		op.AppendMessage(reason)
+		err = store.DB.ChangeBridgeOperationStatus(op, prevStatus)
+		if err != nil && !errors.Is(err, types.ErrStatusConflict) {
+			log.Printf("Cannot update bridge operation status: %v", err)
+		}
+		return
+	}
+	redriveOperation(op, reason)
+}
@@ -0,0 +1,238 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"gobglbridge/EVMRPC"
+	"gobglbridge/config"
+	"gobglbridge/store"
+	"gobglbridge/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// outgoing EVM txs are finalized by scanner (Transfer log), reverted or dropped ones
+// never emit a log, so their receipts are checked here and operation is re-driven
+func Worker_trackEVM(ctx context.Context) {
+	// lost broadcasts are repeated right after restart
+	trackEVMOps(ctx)
+	for sleep(ctx, 30*time.Second) {
+		trackEVMOps(ctx)
+	}
+}
+
+func trackEVMOps(ctx context.Context) {
+	for _, status := range []string{"executing", "returning"} {
+		ops, err := store.DB.FindAllBridgeOperationsByStatus(status)
+		if err != nil {
+			log.Printf("Error getting %s bridge operations: %v", status, err)
+			continue
+		}
+
+		for _, op := range ops {
+			// BGL destination is tracked by BGL scanner
+			if op.DestChain <= 0 {
+				continue
+			}
+			if _, ok := config.EVMChains[op.DestChain]; !ok {
+				continue
+			}
+			if op.DestTxHash == "" {
+				// signed txs are stored before broadcast, so nothing was sent
+				if time.Since(time.Unix(op.TsUpdated, 0)) > time.Duration(config.Config.EVM.DropTimeout)*time.Minute {
+					log.Printf("Bridge operation %s is %s but no tx was signed, nothing sent", op.ID, op.Status)
+				}
+				continue
+			}
+
+			reason, stuck, err := checkEVMTx(ctx, op)
+			if err != nil {
+				log.Printf("Error checking %s tx %s: %v", config.EVMChains[op.DestChain].Name, op.DestTxHash, err)
+				continue
+			}
+			if reason != "" {
+				redriveOperation(op, reason)
+			} else if stuck != nil {
+				bumpEVMTx(ctx, op, stuck)
+			}
+		}
+	}
+}
+
+// returns why the destination tx will never transfer funds, empty if it's fine or still pending;
+// tx is returned too while it waits in mempool
+func checkEVMTx(ctx context.Context, op *types.BridgeOperation) (string, *ethtypes.Transaction, error) {
+	// replacements share the nonce, at most one of them gets mined;
+	// replaced hashes are recorded oldest first, checked newest first
+	txHashes := []string{op.DestTxHash}
+	for i := len(op.ReplacedTxHashes) - 1; i >= 0; i-- {
+		txHashes = append(txHashes, op.ReplacedTxHashes[i])
+	}
+
+	for _, txHash := range txHashes {
+		receipt, err := EVMRPC.WithClient(
+			op.DestChain, func(client *ethclient.Client) (*ethtypes.Receipt, error) {
+				receipt, err := client.TransactionReceipt(ctx, common.HexToHash(txHash))
+				if errors.Is(err, ethereum.NotFound) {
+					return nil, nil
+				}
+				return receipt, err
+			},
+		)
+		if err != nil {
+			return "", nil, err
+		}
+		if receipt == nil {
+			continue
+		}
+
+		if receipt.Status == ethtypes.ReceiptStatusFailed {
+			// nonce is consumed by the reverted tx
+			op.DestTxNonce = nil
+			op.ReplacedTxHashes = nil
+			op.DestRawTx = ""
+			return fmt.Sprintf("tx %s reverted in block %s", txHash, receipt.BlockNumber), nil, nil
+		}
+		return "", nil, nil
+	}
+
+	// not mined, check whether any node still knows the tx (newest first)
+	for _, txHash := range txHashes {
+		pending := false
+		tx, err := EVMRPC.WithClient(
+			op.DestChain, func(client *ethclient.Client) (*ethtypes.Transaction, error) {
+				tx, isPending, err := client.TransactionByHash(ctx, common.HexToHash(txHash))
+				if errors.Is(err, ethereum.NotFound) {
+					return nil, nil
+				}
+				pending = isPending
+				return tx, err
+			},
+		)
+		if err != nil {
+			return "", nil, err
+		}
+		if tx != nil {
+			if !pending {
+				// mined meanwhile
+				return "", nil, nil
+			}
+			return "", tx, nil
+		}
+	}
+
+	// stored signed tx may have never reached a node
+	if op.DestRawTx != "" {
+		err := rebroadcastEVMTx(ctx, op)
+		if err == nil {
+			return "", nil, nil
+		}
+		log.Printf("Error rebroadcasting tx %s: %v", op.DestTxHash, err)
+	}
+
+	if op.DestTxNonce != nil {
+		nonce, err := EVMRPC.WithClient(
+			op.DestChain, func(client *ethclient.Client) (uint64, error) {
+				return client.NonceAt(ctx, common.HexToAddress(config.Config.EVM.PublicAddress), nil)
+			},
+		)
+		if err != nil {
+			return "", nil, err
+		}
+		// nonce was used by another tx, ours can never be mined
+		if nonce > *op.DestTxNonce {
+			op.DestTxNonce = nil
+			op.ReplacedTxHashes = nil
+			op.DestRawTx = ""
+			return fmt.Sprintf("tx %s dropped, nonce reused", op.DestTxHash), nil, nil
+		}
+	}
+
+	// unknown tx may just not have propagated yet
+	if time.Since(time.Unix(op.TsUpdated, 0)) < time.Duration(config.Config.EVM.DropTimeout)*time.Minute {
+		return "", nil, nil
+	}
+	// without nonce a new tx could be mined along with the original one
+	if op.DestTxNonce == nil {
+		log.Printf("Bridge operation %s tx %s is not known to RPC nodes, nonce unknown, cannot re-drive safely", op.ID, op.DestTxHash)
+		return "", nil, nil
+	}
+	return fmt.Sprintf("tx %s never mined", op.DestTxHash), nil, nil
+}
+
+// fee paid by mined tx, gas used times effective gas price
+func minedEVMTxFee(ctx context.Context, chainId int, txHash string) (*big.Int, error) {
+	receipt, err := EVMRPC.WithClient(
+		chainId, func(client *ethclient.Client) (*ethtypes.Receipt, error) {
+			return client.TransactionReceipt(ctx, common.HexToHash(txHash))
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.EffectiveGasPrice == nil {
+		return nil, fmt.Errorf("no effective gas price in receipt of %s", txHash)
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice), nil
+}
+
+func rebroadcastEVMTx(ctx context.Context, op *types.BridgeOperation) error {
+	raw, err := hexutil.Decode(op.DestRawTx)
+	if err != nil {
+		return err
+	}
+	var tx ethtypes.Transaction
+	err = tx.UnmarshalBinary(raw)
+	if err != nil {
+		return err
+	}
+	if tx.Hash().Hex() != op.DestTxHash {
+		return fmt.Errorf("stored tx hash %s does not match", tx.Hash().Hex())
+	}
+
+	log.Printf("Bridge operation %s: rebroadcasting %s tx %s", op.ID, config.EVMChains[op.DestChain].Name, op.DestTxHash)
+	return broadcastEVMTx(ctx, op.DestChain, &tx)
+}
+
+// moves operation back to pending for executor to send again, or to failure when out of retries
+func redriveOperation(op *types.BridgeOperation, reason string) {
+	prevStatus := op.Status
+
+	if op.Retries >= config.Config.EVM.MaxRetries {
+		if prevStatus == "returning" {
+			op.Status = "returnfail"
+		} else {
+			op.Status = "failed"
+		}
+		reason = fmt.Sprintf("%s, giving up after %d retries", reason, op.Retries)
+	} else {
+		op.Retries++
+		op.Status = "pending"
+		reason = fmt.Sprintf("%s, retry %d/%d", reason, op.Retries, config.Config.EVM.MaxRetries)
+		if prevStatus == "returning" && op.DestTxNonce != nil {
+			// WBGL return tx may still be mined, so it's signed again with the pinned nonce
+			// and operation never turns into a BGL payout as well
+			op.ForceReturn = true
+		} else if prevStatus == "returning" {
+			// WBGL to BGL operation, executor tries to send BGL again first
+			op.DestChain = 0
+		}
+	}
+
+	log.Printf("Bridge operation %s: %s", op.ID, reason)
+	op.AppendMessage(reason)
+
+	err := store.DB.ChangeBridgeOperationStatus(op, prevStatus)
+	if err != nil && !errors.Is(err, types.ErrStatusConflict) {
+		log.Printf("Error re-driving bridge operation %s: %v", op.ID, err)
+	}
+}